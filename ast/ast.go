@@ -242,6 +242,31 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+type ConditionalExpression struct {
+	Token       token.Token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (ce *ConditionalExpression) expressionNode() {}
+func (ce *ConditionalExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+func (ce *ConditionalExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ce.Condition.String())
+	out.WriteString(" ? ")
+	out.WriteString(ce.Consequence.String())
+	out.WriteString(" : ")
+	out.WriteString(ce.Alternative.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type WhileExpression struct {
 	Token       token.Token
 	Condition   Expression
@@ -286,6 +311,84 @@ func (fe *ForExpression) String() string {
 	return out.String()
 }
 
+type ForInExpression struct {
+	Token       token.Token
+	Variable    *Identifier
+	Iterable    Expression
+	Consequence *BlockStatement
+}
+
+func (fe *ForInExpression) expressionNode() {}
+func (fe *ForInExpression) TokenLiteral() string {
+	return fe.Token.Literal
+}
+func (fe *ForInExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for ")
+	out.WriteString(fe.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fe.Iterable.String())
+	out.WriteString(" ")
+	out.WriteString(fe.Consequence.String())
+
+	return out.String()
+}
+
+type MatchCase struct {
+	Token   token.Token
+	Pattern Expression
+	Guard   Expression
+	Body    *BlockStatement
+}
+
+func (mc *MatchCase) TokenLiteral() string {
+	return mc.Token.Literal
+}
+func (mc *MatchCase) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("case ")
+	out.WriteString(mc.Pattern.String())
+
+	if mc.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(mc.Guard.String())
+	}
+
+	out.WriteString(" => ")
+	out.WriteString(mc.Body.String())
+
+	return out.String()
+}
+
+type MatchExpression struct {
+	Token token.Token
+	Value Expression
+	Cases []*MatchCase
+}
+
+func (me *MatchExpression) expressionNode() {}
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	cases := []string{}
+	for _, c := range me.Cases {
+		cases = append(cases, c.String())
+	}
+
+	out.WriteString("match ")
+	out.WriteString(me.Value.String())
+	out.WriteString(" {")
+	out.WriteString(strings.Join(cases, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
@@ -371,6 +474,22 @@ func (sl *StringLiteral) String() string {
 	return sl.Token.Literal
 }
 
+// InterpolatedString is an f-string. Text between the embedded expressions
+// is kept as string literals, Specs holds the format spec of every part.
+type InterpolatedString struct {
+	Token token.Token
+	Parts []Expression
+	Specs []string
+}
+
+func (is *InterpolatedString) expressionNode() {}
+func (is *InterpolatedString) TokenLiteral() string {
+	return is.Token.Literal
+}
+func (is *InterpolatedString) String() string {
+	return "f\"" + is.Token.Literal + "\""
+}
+
 type ArrayLiteral struct {
 	Token    token.Token
 	Elements []Expression
@@ -417,9 +536,34 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// MemberExpression is `object.name`, used to access module members and
+// string keys of hashes.
+type MemberExpression struct {
+	Token  token.Token
+	Object Expression
+	Member *Identifier
+}
+
+func (me *MemberExpression) expressionNode() {}
+func (me *MemberExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(me.Object.String())
+	out.WriteString(".")
+	out.WriteString(me.Member.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type HashLiteral struct {
 	Token token.Token
 	Pairs map[Expression]Expression
+	Keys  []Expression
 }
 
 func (hl *HashLiteral) expressionNode() {}
@@ -430,8 +574,8 @@ func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", key.String(), value.String()))
+	for _, key := range hl.Keys {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", key.String(), hl.Pairs[key].String()))
 	}
 
 	out.WriteString("{")
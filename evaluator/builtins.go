@@ -5,6 +5,7 @@ import (
 	"doge/object"
 	"doge/parser"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"os"
@@ -15,6 +16,9 @@ import (
 
 var builtins = map[string]*object.Builtin{}
 
+// modules are looked up after builtins, e.g. `math.sqrt(2)`.
+var modules = map[string]*object.Module{}
+
 func InitBuiltins() {
 	builtins["append"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
@@ -26,6 +30,10 @@ func InitBuiltins() {
 			}
 
 			arr := args[0].(*object.Array)
+			if arr.Frozen {
+				return NewError("cannot modify frozen array")
+			}
+
 			arr.Elements = append(arr.Elements, args[1])
 
 			return &object.Null{}
@@ -46,6 +54,9 @@ func InitBuiltins() {
 
 			index := args[1].(*object.Integer)
 			arr := args[0].(*object.Array)
+			if arr.Frozen {
+				return NewError("cannot modify frozen array")
+			}
 
 			idx := index.Value
 
@@ -65,23 +76,17 @@ func InitBuiltins() {
 		Documentation: "This function removes on object from an array!",
 	}
 	builtins["print"] = &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) < 1 {
-				return NewError("print needs at least one argument. got=%d", len(args))
-			}
-
-			var elements []string
-			for _, arg := range args {
-				elements = append(elements, arg.Inspect())
-			}
-
-			out := strings.Join(elements, " ")
-
-			fmt.Println(out)
-			return &object.Null{}
-		},
+		Fn:            printBuiltin,
 		Documentation: "This function prints every object that is given to it, multiple arguments will be seperated by a space!",
 	}
+	builtins["print_with"] = &object.Builtin{
+		Fn:            printWithBuiltin,
+		Documentation: "This function prints like print, with a hash of options first that changes the separator, line ending and output stream. Usage: print_with({\"sep\": \", \", \"end\": \"\", \"stream\": \"stderr\"}, ...args)",
+	}
+	builtins["format"] = &object.Builtin{
+		Fn:            formatBuiltin,
+		Documentation: "This function formats its arguments printf style, e.g. format(\"%-8s|%6.2f\", name, price)! Directives are %[flags][width][.precision]verb with flags -^0+ # and verbs v d f e g x X o b c s, %% prints a percent sign!",
+	}
 	builtins["len"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if len(args) != 1 {
@@ -95,11 +100,13 @@ func InitBuiltins() {
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			case *object.Hash:
 				return &object.Integer{Value: int64(len(arg.Pairs))}
+			case *object.Set:
+				return &object.Integer{Value: int64(arg.Len())}
 			default:
 				return NewError("argument to `len` not supported, got=%s", args[0].Type())
 			}
 		},
-		Documentation: "This function returns the length of an array, string or hash!",
+		Documentation: "This function returns the length of an array, string, hash or set!",
 	}
 	builtins["sum"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
@@ -312,7 +319,9 @@ func InitBuiltins() {
 					return NewError("errors while importing file '%s'\n\t%s", strObj.Value, strings.Join(p.Errors(), "\n\t"))
 				}
 
-				_ = Eval(program, env)
+				if err, ok := Eval(program, env).(*object.Error); ok && err.Exit {
+					return err
+				}
 			}
 
 			env.Set("__name__", &object.String{Value: "__main__"})
@@ -332,6 +341,70 @@ func InitBuiltins() {
 		Fn:            stringBuiltin,
 		Documentation: "Converts native type to string!",
 	}
+	builtins["compare"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return NewError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			result, ok := object.Compare(args[0], args[1])
+			if !ok {
+				return NewError("cannot compare %s and %s", args[0].Type(), args[1].Type())
+			}
+
+			return &object.Integer{Value: int64(result)}
+		},
+		Documentation: "This function returns -1, 0 or 1 depending on how two values are ordered!",
+	}
+	builtins["set"] = &object.Builtin{
+		Fn:            setBuiltin,
+		Documentation: "This function creates a set from the elements of an iterable!",
+	}
+	builtins["freeze"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return NewError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return NewError("argument to `freeze` must be ARRAY, got %s", args[0].Type())
+			}
+
+			return arr.Freeze()
+		},
+		Documentation: "This function returns an immutable copy of an array, which can be used as a hash key!",
+	}
+	builtins["input"] = &object.Builtin{
+		Fn:            inputBuiltin,
+		Documentation: "This function prints an optional prompt and reads a line from stdin, returning null at the end of input!",
+	}
+	builtins["read_stdin"] = &object.Builtin{
+		Fn:            readStdinBuiltin,
+		Documentation: "This function reads everything left on stdin!",
+	}
+	builtins["stdin_lines"] = &object.Builtin{
+		Fn:            stdinLinesBuiltin,
+		Documentation: "This function returns an iterator over the lines of stdin!",
+	}
+	builtins["try"] = &object.Builtin{
+		Fn:            tryBuiltin,
+		Documentation: "This function calls a function and returns [result, null], or [null, message] if it fails. Usage: try(fn, args...)",
+	}
+
+	initStringBuiltins()
+	initArrayBuiltins()
+	initHashBuiltins()
+
+	modules["math"] = NewMathModule()
+	modules["random"] = NewRandomModule()
+	modules["fs"] = NewFsModule()
+	modules["os"] = NewOsModule()
+	modules["json"] = NewJsonModule()
+	modules["re"] = NewReModule()
+	modules["time"] = NewTimeModule()
+	modules["process"] = NewProcessModule()
+	modules["http"] = NewHttpModule()
 }
 
 func helpBuiltin(env *object.Environment, args ...object.Object) object.Object {
@@ -361,7 +434,7 @@ func mapBuiltin(env *object.Environment, args ...object.Object) object.Object {
 		funct := args[1].(*object.Function)
 
 		for _, elm := range arr.Elements {
-			res := RunFunction(funct, []object.Object{elm})
+			res := RunFunction(funct, []object.Object{elm}, env)
 			elements = append(elements, res)
 		}
 	} else {
@@ -394,7 +467,7 @@ func filterBuiltin(env *object.Environment, args ...object.Object) object.Object
 		funct := args[1].(*object.Function)
 
 		for _, elm := range arr.Elements {
-			res := RunFunction(funct, []object.Object{elm})
+			res := RunFunction(funct, []object.Object{elm}, env)
 
 			if IsTruthy(res) {
 				elements = append(elements, elm)
@@ -422,3 +495,233 @@ func stringBuiltin(env *object.Environment, args ...object.Object) object.Object
 
 	return &object.String{Value: args[0].Inspect()}
 }
+
+func setBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return NewError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+	}
+
+	set := object.NewSet()
+	if len(args) == 0 {
+		return set
+	}
+	if !IsIterable(args[0]) {
+		return NewError("argument to `set` must be iterable, got %s", args[0].Type())
+	}
+
+	var err *object.Error
+	iterErr := Iterate(args[0], func(elm object.Object) bool {
+		if !object.IsHashable(elm) {
+			err = NewError("unusable as set element: %s", elm.Type())
+			return false
+		}
+
+		set.Add(elm)
+		return true
+	})
+	if iterErr != nil {
+		return iterErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return set
+}
+
+func inputBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 1); err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		prompt, err := StringArgument("input", args, 0)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(env.Context().Stdout, prompt)
+	}
+
+	line, err := env.Context().Stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return NewError("cannot read from stdin: %s", err)
+	}
+	if err == io.EOF && line == "" {
+		return NULL
+	}
+
+	return &object.String{Value: strings.TrimRight(line, "\r\n")}
+}
+
+func readStdinBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 0); err != nil {
+		return err
+	}
+
+	buf, err := io.ReadAll(env.Context().Stdin)
+	if err != nil {
+		return NewError("cannot read from stdin: %s", err)
+	}
+
+	return &object.String{Value: string(buf)}
+}
+
+func stdinLinesBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 0); err != nil {
+		return err
+	}
+
+	return LineIterator("stdin_lines", env.Context().Stdin, nil)
+}
+
+// tryBuiltin turns an error raised by fn into a value, so scripts can
+// recover from failures like a missing file. Calls to `os.exit` still end
+// the program.
+func tryBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, -1); err != nil {
+		return err
+	}
+	fn, err := FunctionArgument("try", args, 0)
+	if err != nil {
+		return err
+	}
+
+	result := ApplyFunction(fn, args[1:], env)
+	if err, ok := result.(*object.Error); ok {
+		if err.Exit {
+			return err
+		}
+		return &object.Array{Elements: []object.Object{NULL, &object.String{Value: err.Message}}}
+	}
+
+	return &object.Array{Elements: []object.Object{result, NULL}}
+}
+
+func printBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return NewError("print needs at least one argument. got=%d", len(args))
+	}
+
+	return printObjects(env.Context().Stdout, args, " ", "\n")
+}
+
+// printWithBuiltin takes its options as first argument, so every hash after
+// them is printed like any other value.
+func printWithBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, -1); err != nil {
+		return err
+	}
+	options, err := HashArgument("print_with", args, 0)
+	if err != nil {
+		return err
+	}
+
+	sep, end := " ", "\n"
+	out := env.Context().Stdout
+
+	for _, pair := range options.Ordered() {
+		key, _ := pair.Key.(*object.String)
+		if key == nil {
+			return NewError("unknown option for `print_with`: %s", pair.Key.Inspect())
+		}
+		value, ok := pair.Value.(*object.String)
+		if !ok {
+			return NewError("print option `%s` must be STRING, got %s", key.Value, pair.Value.Type())
+		}
+
+		switch key.Value {
+		case "sep":
+			sep = value.Value
+		case "end":
+			end = value.Value
+		case "stream":
+			switch value.Value {
+			case "stdout":
+				out = env.Context().Stdout
+			case "stderr":
+				out = env.Context().Stderr
+			default:
+				return NewError("print stream must be \"stdout\" or \"stderr\", got %q", value.Value)
+			}
+		default:
+			return NewError("unknown option for `print_with`: %s", key.Value)
+		}
+	}
+
+	return printObjects(out, args[1:], sep, end)
+}
+
+func printObjects(out io.Writer, args []object.Object, sep, end string) object.Object {
+	var elements []string
+	for _, arg := range args {
+		elements = append(elements, arg.Inspect())
+	}
+
+	fmt.Fprint(out, strings.Join(elements, sep)+end)
+	return &object.Null{}
+}
+
+func formatBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return NewError("format needs at least one argument. got=%d", len(args))
+	}
+
+	format, ok := args[0].(*object.String)
+	if !ok {
+		return NewError("first argument to `format` must be STRING, got %s", args[0].Type())
+	}
+
+	out, err := FormatString(format.Value, args[1:])
+	if err != nil {
+		return err
+	}
+
+	return &object.String{Value: out}
+}
+
+var ordinals = []string{"first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth", "ninth", "tenth"}
+
+// CheckArgumentCount returns an error if the number of arguments is not
+// between min and max. A max of -1 allows any number of arguments.
+func CheckArgumentCount(args []object.Object, min, max int) *object.Error {
+	if len(args) >= min && (max < 0 || len(args) <= max) {
+		return nil
+	}
+
+	want := fmt.Sprint(min)
+	if max < 0 {
+		want = fmt.Sprintf("at least %d", min)
+	} else if max != min {
+		want = fmt.Sprintf("%d to %d", min, max)
+	}
+
+	return NewError("wrong number of arguments. got=%d, want=%s", len(args), want)
+}
+
+// ArgumentTypeError reports an argument of the wrong type, e.g. "second
+// argument to `split` must be STRING, got INTEGER".
+func ArgumentTypeError(name string, idx int, want string, got object.Object) *object.Error {
+	position := fmt.Sprintf("argument %d", idx+1)
+	if idx < len(ordinals) {
+		position = ordinals[idx] + " argument"
+	}
+
+	return NewError("%s to `%s` must be %s, got %s", position, name, want, got.Type())
+}
+
+func StringArgument(name string, args []object.Object, idx int) (string, *object.Error) {
+	str, ok := args[idx].(*object.String)
+	if !ok {
+		return "", ArgumentTypeError(name, idx, "STRING", args[idx])
+	}
+
+	return str.Value, nil
+}
+
+func IntegerArgument(name string, args []object.Object, idx int) (int64, *object.Error) {
+	integer, ok := args[idx].(*object.Integer)
+	if !ok {
+		return 0, ArgumentTypeError(name, idx, "INTEGER", args[idx])
+	}
+
+	return integer.Value, nil
+}
@@ -0,0 +1,547 @@
+package evaluator
+
+import (
+	"doge/object"
+	"sort"
+)
+
+// Builtins that return arrays always build a new one, only `insert` and
+// `pop` modify the array passed to them.
+func initArrayBuiltins() {
+	builtins["sort"] = &object.Builtin{
+		Fn:            sortBuiltin,
+		Documentation: "This function returns a sorted copy of an array, optionally ordered by a key function!",
+	}
+	builtins["reduce"] = &object.Builtin{
+		Fn:            reduceBuiltin("reduce"),
+		Documentation: "This function combines the elements of an array with a function. Usage: reduce(arr, fn, initial)",
+	}
+	builtins["fold"] = &object.Builtin{
+		Fn:            reduceBuiltin("fold"),
+		Documentation: "This function is an alias for reduce!",
+	}
+	builtins["zip"] = &object.Builtin{
+		Fn:            zipBuiltin,
+		Documentation: "This function pairs up the elements of arrays, stopping at the shortest!",
+	}
+	builtins["enumerate"] = &object.Builtin{
+		Fn:            enumerateBuiltin,
+		Documentation: "This function returns [index, element] pairs for an array, counting from an optional start!",
+	}
+	builtins["any"] = &object.Builtin{
+		Fn:            quantifierBuiltin("any", true),
+		Documentation: "This function checks if any element, or result of a function, is truthy!",
+	}
+	builtins["all"] = &object.Builtin{
+		Fn:            quantifierBuiltin("all", false),
+		Documentation: "This function checks if all elements, or results of a function, are truthy!",
+	}
+	builtins["index_of"] = &object.Builtin{
+		Fn:            indexOfBuiltin,
+		Documentation: "This function returns the index of an element in an array, or -1 if it is not found!",
+	}
+	builtins["contains"] = &object.Builtin{
+		Fn:            containsBuiltin,
+		Documentation: "This function checks if an array contains an element!",
+	}
+	builtins["insert"] = &object.Builtin{
+		Fn:            insertBuiltin,
+		Documentation: "This function inserts an element into an array at an index!",
+	}
+	builtins["pop"] = &object.Builtin{
+		Fn:            popBuiltin,
+		Documentation: "This function removes and returns the last element, or the element at an index, of an array!",
+	}
+	builtins["concat"] = &object.Builtin{
+		Fn:            concatBuiltin,
+		Documentation: "This function joins arrays into a new array!",
+	}
+	builtins["flatten"] = &object.Builtin{
+		Fn:            flattenBuiltin,
+		Documentation: "This function flattens nested arrays, one level deep by default or to the given depth!",
+	}
+	builtins["unique"] = &object.Builtin{
+		Fn:            uniqueBuiltin,
+		Documentation: "This function removes duplicate elements from an array, keeping the first occurrence!",
+	}
+	builtins["chunk"] = &object.Builtin{
+		Fn:            chunkBuiltin,
+		Documentation: "This function splits an array into arrays of the given size!",
+	}
+	builtins["copy"] = &object.Builtin{
+		Fn:            copyBuiltin("copy", false),
+		Documentation: "This function returns a shallow copy of an array, hash or set!",
+	}
+	builtins["deepcopy"] = &object.Builtin{
+		Fn:            copyBuiltin("deepcopy", true),
+		Documentation: "This function returns a copy of an array, hash or set and everything inside it!",
+	}
+}
+
+func ArrayArgument(name string, args []object.Object, idx int) (*object.Array, *object.Error) {
+	arr, ok := args[idx].(*object.Array)
+	if !ok {
+		return nil, ArgumentTypeError(name, idx, "ARRAY", args[idx])
+	}
+
+	return arr, nil
+}
+
+func FunctionArgument(name string, args []object.Object, idx int) (object.Object, *object.Error) {
+	if args[idx].Type() != object.FUNCTION_OBJ && args[idx].Type() != object.BUILTIN_OBJ {
+		return nil, ArgumentTypeError(name, idx, "FUNCTION", args[idx])
+	}
+
+	return args[idx], nil
+}
+
+func sortBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("sort", args, 0)
+	if err != nil {
+		return err
+	}
+
+	elements := append([]object.Object{}, arr.Elements...)
+	keys := elements
+	if len(args) == 2 {
+		fn, err := FunctionArgument("sort", args, 1)
+		if err != nil {
+			return err
+		}
+
+		keys = make([]object.Object, len(elements))
+		for i, el := range elements {
+			key := ApplyFunction(fn, []object.Object{el}, env)
+			if IsError(key) {
+				return key
+			}
+			keys[i] = key
+		}
+	}
+
+	// sort indices so the keys and elements stay in step
+	order := make([]int, len(elements))
+	for i := range order {
+		order[i] = i
+	}
+
+	var sortErr *object.Error
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := keys[order[i]], keys[order[j]]
+		cmp, ok := object.Compare(a, b)
+		if !ok && sortErr == nil {
+			sortErr = NewError("cannot compare %s and %s", a.Type(), b.Type())
+		}
+		return cmp < 0
+	})
+	if sortErr != nil {
+		return sortErr
+	}
+
+	sorted := make([]object.Object, len(order))
+	for i, idx := range order {
+		sorted[i] = elements[idx]
+	}
+
+	return &object.Array{Elements: sorted}
+}
+
+func reduceBuiltin(name string) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 2, 3); err != nil {
+			return err
+		}
+		arr, err := ArrayArgument(name, args, 0)
+		if err != nil {
+			return err
+		}
+		fn, err := FunctionArgument(name, args, 1)
+		if err != nil {
+			return err
+		}
+
+		elements := arr.Elements
+		var acc object.Object
+		if len(args) == 3 {
+			acc = args[2]
+		} else if len(elements) == 0 {
+			return NewError("`%s` of empty array with no initial value", name)
+		} else {
+			acc, elements = elements[0], elements[1:]
+		}
+
+		for _, el := range elements {
+			acc = ApplyFunction(fn, []object.Object{acc, el}, env)
+			if IsError(acc) {
+				return acc
+			}
+		}
+
+		return acc
+	}
+}
+
+func zipBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, -1); err != nil {
+		return err
+	}
+
+	arrays := make([]*object.Array, len(args))
+	length := -1
+	for i := range args {
+		arr, ok := args[i].(*object.Array)
+		if !ok {
+			return NewError("arguments to `zip` must be ARRAY, got %s", args[i].Type())
+		}
+		arrays[i] = arr
+		if length < 0 || len(arr.Elements) < length {
+			length = len(arr.Elements)
+		}
+	}
+
+	zipped := make([]object.Object, length)
+	for i := range zipped {
+		tuple := make([]object.Object, len(arrays))
+		for j, arr := range arrays {
+			tuple[j] = arr.Elements[i]
+		}
+		zipped[i] = &object.Array{Elements: tuple}
+	}
+
+	return &object.Array{Elements: zipped}
+}
+
+func enumerateBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("enumerate", args, 0)
+	if err != nil {
+		return err
+	}
+
+	start := int64(0)
+	if len(args) == 2 {
+		if start, err = IntegerArgument("enumerate", args, 1); err != nil {
+			return err
+		}
+	}
+
+	pairs := make([]object.Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		pairs[i] = &object.Array{Elements: []object.Object{&object.Integer{Value: start + int64(i)}, el}}
+	}
+
+	return &object.Array{Elements: pairs}
+}
+
+// quantifierBuiltin builds `any` and `all`, which stop at the first element
+// whose truthiness equals stopAt.
+func quantifierBuiltin(name string, stopAt bool) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 1, 2); err != nil {
+			return err
+		}
+		arr, err := ArrayArgument(name, args, 0)
+		if err != nil {
+			return err
+		}
+
+		var fn object.Object
+		if len(args) == 2 {
+			if fn, err = FunctionArgument(name, args, 1); err != nil {
+				return err
+			}
+		}
+
+		for _, el := range arr.Elements {
+			if fn != nil {
+				el = ApplyFunction(fn, []object.Object{el}, env)
+				if IsError(el) {
+					return el
+				}
+			}
+			if IsTruthy(el) == stopAt {
+				return NativeBoolToBooleanObject(stopAt)
+			}
+		}
+
+		return NativeBoolToBooleanObject(!stopAt)
+	}
+}
+
+func IndexOf(arr *object.Array, obj object.Object) int {
+	for i, el := range arr.Elements {
+		if object.Equal(el, obj) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func indexOfBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("index_of", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return &object.Integer{Value: int64(IndexOf(arr, args[1]))}
+}
+
+func containsBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("contains", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return NativeBoolToBooleanObject(IndexOf(arr, args[1]) >= 0)
+}
+
+// ArrayIndex resolves idx, which may count from the end if negative, to a
+// position in an array of the given length. Positions up to and including
+// limit are valid.
+func ArrayIndex(idx int64, length, limit int) (int, bool) {
+	if idx < 0 {
+		idx += int64(length)
+	}
+	if idx < 0 || idx > int64(limit) {
+		return 0, false
+	}
+
+	return int(idx), true
+}
+
+func insertBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 3, 3); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("insert", args, 0)
+	if err != nil {
+		return err
+	}
+	idx, err := IntegerArgument("insert", args, 1)
+	if err != nil {
+		return err
+	}
+	if arr.Frozen {
+		return NewError("cannot modify frozen array")
+	}
+
+	pos, ok := ArrayIndex(idx, len(arr.Elements), len(arr.Elements))
+	if !ok {
+		return NewError("Index out of bounds!")
+	}
+
+	arr.Elements = append(arr.Elements, nil)
+	copy(arr.Elements[pos+1:], arr.Elements[pos:])
+	arr.Elements[pos] = args[2]
+
+	return NULL
+}
+
+func popBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("pop", args, 0)
+	if err != nil {
+		return err
+	}
+	if arr.Frozen {
+		return NewError("cannot modify frozen array")
+	}
+	if len(arr.Elements) == 0 {
+		return NewError("pop from empty array")
+	}
+
+	idx := int64(-1)
+	if len(args) == 2 {
+		if idx, err = IntegerArgument("pop", args, 1); err != nil {
+			return err
+		}
+	}
+
+	pos, ok := ArrayIndex(idx, len(arr.Elements), len(arr.Elements)-1)
+	if !ok {
+		return NewError("Index out of bounds!")
+	}
+
+	el := arr.Elements[pos]
+	arr.Elements = append(arr.Elements[:pos], arr.Elements[pos+1:]...)
+
+	return el
+}
+
+func concatBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	elements := []object.Object{}
+
+	for _, arg := range args {
+		arr, ok := arg.(*object.Array)
+		if !ok {
+			return NewError("arguments to `concat` must be ARRAY, got %s", arg.Type())
+		}
+		elements = append(elements, arr.Elements...)
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func flattenBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("flatten", args, 0)
+	if err != nil {
+		return err
+	}
+
+	depth := int64(1)
+	if len(args) == 2 {
+		if depth, err = IntegerArgument("flatten", args, 1); err != nil {
+			return err
+		}
+	}
+
+	return &object.Array{Elements: Flatten(arr.Elements, depth)}
+}
+
+func Flatten(elements []object.Object, depth int64) []object.Object {
+	flat := []object.Object{}
+
+	for _, el := range elements {
+		if arr, ok := el.(*object.Array); ok && depth > 0 {
+			flat = append(flat, Flatten(arr.Elements, depth-1)...)
+		} else {
+			flat = append(flat, el)
+		}
+	}
+
+	return flat
+}
+
+func uniqueBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("unique", args, 0)
+	if err != nil {
+		return err
+	}
+
+	seen := object.NewSet()
+	unique := &object.Array{Elements: []object.Object{}}
+	for _, el := range arr.Elements {
+		if object.IsHashable(el) {
+			if seen.Contains(el) {
+				continue
+			}
+			seen.Add(el)
+		} else if IndexOf(unique, el) >= 0 {
+			continue
+		}
+		unique.Elements = append(unique.Elements, el)
+	}
+
+	return unique
+}
+
+func chunkBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("chunk", args, 0)
+	if err != nil {
+		return err
+	}
+	size, err := IntegerArgument("chunk", args, 1)
+	if err != nil {
+		return err
+	}
+	if size <= 0 {
+		return NewError("chunk size must be positive, got %d", size)
+	}
+
+	chunks := []object.Object{}
+	for i := 0; i < len(arr.Elements); i += int(size) {
+		end := i + int(size)
+		if end > len(arr.Elements) {
+			end = len(arr.Elements)
+		}
+		chunk := append([]object.Object{}, arr.Elements[i:end]...)
+		chunks = append(chunks, &object.Array{Elements: chunk})
+	}
+
+	return &object.Array{Elements: chunks}
+}
+
+func copyBuiltin(name string, deep bool) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 1, 1); err != nil {
+			return err
+		}
+
+		switch args[0].(type) {
+		case *object.Array, *object.Hash, *object.Set:
+			return Copy(args[0], deep)
+		default:
+			return NewError("argument to `%s` must be ARRAY, HASH or SET, got %s", name, args[0].Type())
+		}
+	}
+}
+
+// Copy copies arrays, hashes and sets, and with deep also everything stored
+// in them. Other objects are immutable and returned as they are. Copies of
+// frozen arrays can be modified, except when they are hash keys.
+func Copy(obj object.Object, deep bool) object.Object {
+	return copyObject(obj, deep, map[object.Object]object.Object{})
+}
+
+// copyObject remembers the copies it made in seen, so arrays and hashes that
+// contain themselves are copied without recursing forever.
+func copyObject(obj object.Object, deep bool, seen map[object.Object]object.Object) object.Object {
+	if copied, ok := seen[obj]; ok {
+		return copied
+	}
+
+	inner := func(obj object.Object) object.Object {
+		if deep {
+			return copyObject(obj, true, seen)
+		}
+		return obj
+	}
+
+	switch obj := obj.(type) {
+	case *object.Array:
+		arr := &object.Array{Elements: make([]object.Object, len(obj.Elements))}
+		seen[obj] = arr
+		for i, el := range obj.Elements {
+			arr.Elements[i] = inner(el)
+		}
+		return arr
+	case *object.Hash:
+		hash := object.NewHash()
+		seen[obj] = hash
+		for _, pair := range obj.Ordered() {
+			hash.Set(pair.Key, inner(pair.Value))
+		}
+		return hash
+	case *object.Set:
+		set := object.NewSet()
+		for _, el := range obj.Elements() {
+			set.Add(el)
+		}
+		return set
+	default:
+		return obj
+	}
+}
@@ -0,0 +1,197 @@
+package evaluator
+
+import (
+	"doge/object"
+)
+
+// Hashes keep their insertion order, so everything returned here is ordered
+// the way the keys were first added.
+func initHashBuiltins() {
+	builtins["keys"] = &object.Builtin{
+		Fn:            hashListBuiltin("keys", func(pair object.HashPair) object.Object { return pair.Key }),
+		Documentation: "This function returns the keys of a hash!",
+	}
+	builtins["values"] = &object.Builtin{
+		Fn:            hashListBuiltin("values", func(pair object.HashPair) object.Object { return pair.Value }),
+		Documentation: "This function returns the values of a hash!",
+	}
+	builtins["items"] = &object.Builtin{
+		Fn: hashListBuiltin("items", func(pair object.HashPair) object.Object {
+			return &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+		}),
+		Documentation: "This function returns the [key, value] pairs of a hash!",
+	}
+	builtins["has"] = &object.Builtin{
+		Fn:            hasBuiltin,
+		Documentation: "This function checks if a hash contains a key!",
+	}
+	builtins["get"] = &object.Builtin{
+		Fn:            getBuiltin,
+		Documentation: "This function returns the value for a key, or a default if the key is missing. Usage: get(hash, key, default)",
+	}
+	builtins["delete"] = &object.Builtin{
+		Fn:            deleteBuiltin,
+		Documentation: "This function removes a key from a hash and returns its value!",
+	}
+	builtins["merge"] = &object.Builtin{
+		Fn:            mergeBuiltin,
+		Documentation: "This function returns a new hash with the pairs of all given hashes, later ones winning!",
+	}
+	builtins["update"] = &object.Builtin{
+		Fn:            updateBuiltin,
+		Documentation: "This function adds the pairs of other hashes to the first one!",
+	}
+	builtins["from_pairs"] = &object.Builtin{
+		Fn:            fromPairsBuiltin,
+		Documentation: "This function builds a hash from an array of [key, value] pairs!",
+	}
+}
+
+func HashArgument(name string, args []object.Object, idx int) (*object.Hash, *object.Error) {
+	hash, ok := args[idx].(*object.Hash)
+	if !ok {
+		return nil, ArgumentTypeError(name, idx, "HASH", args[idx])
+	}
+
+	return hash, nil
+}
+
+func hashListBuiltin(name string, fn func(object.HashPair) object.Object) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 1, 1); err != nil {
+			return err
+		}
+		hash, err := HashArgument(name, args, 0)
+		if err != nil {
+			return err
+		}
+
+		pairs := hash.Ordered()
+		elements := make([]object.Object, len(pairs))
+		for i, pair := range pairs {
+			elements[i] = fn(pair)
+		}
+
+		return &object.Array{Elements: elements}
+	}
+}
+
+// hashLookup checks the arguments shared by `has`, `get` and `delete`.
+func hashLookup(name string, args []object.Object, min, max int) (*object.Hash, *object.Error) {
+	if err := CheckArgumentCount(args, min, max); err != nil {
+		return nil, err
+	}
+	hash, err := HashArgument(name, args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !object.IsHashable(args[1]) {
+		return nil, NewError("unusable as hash key: %s", args[1].Type())
+	}
+
+	return hash, nil
+}
+
+func hasBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	hash, err := hashLookup("has", args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	_, ok := hash.Get(args[1])
+
+	return NativeBoolToBooleanObject(ok)
+}
+
+func getBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	hash, err := hashLookup("get", args, 2, 3)
+	if err != nil {
+		return err
+	}
+
+	if value, ok := hash.Get(args[1]); ok {
+		return value
+	}
+	if len(args) == 3 {
+		return args[2]
+	}
+
+	return NULL
+}
+
+func deleteBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	hash, err := hashLookup("delete", args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	if value, ok := hash.Delete(args[1]); ok {
+		return value
+	}
+
+	return NULL
+}
+
+func mergeBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	merged := object.NewHash()
+
+	if err := MergeHashes("merge", merged, args); err != nil {
+		return err
+	}
+
+	return merged
+}
+
+func updateBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, -1); err != nil {
+		return err
+	}
+	hash, err := HashArgument("update", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := MergeHashes("update", hash, args[1:]); err != nil {
+		return err
+	}
+
+	return NULL
+}
+
+func MergeHashes(name string, into *object.Hash, hashes []object.Object) *object.Error {
+	for _, arg := range hashes {
+		hash, ok := arg.(*object.Hash)
+		if !ok {
+			return NewError("arguments to `%s` must be HASH, got %s", name, arg.Type())
+		}
+		for _, pair := range hash.Ordered() {
+			into.Set(pair.Key, pair.Value)
+		}
+	}
+
+	return nil
+}
+
+func fromPairsBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("from_pairs", args, 0)
+	if err != nil {
+		return err
+	}
+
+	hash := object.NewHash()
+	for i, el := range arr.Elements {
+		pair, ok := el.(*object.Array)
+		if !ok || len(pair.Elements) != 2 {
+			return NewError("element %d of array passed to `from_pairs` must be a [key, value] pair, got %s", i, el.Inspect())
+		}
+		if !object.IsHashable(pair.Elements[0]) {
+			return NewError("unusable as hash key: %s", pair.Elements[0].Type())
+		}
+		hash.Set(pair.Elements[0], pair.Elements[1])
+	}
+
+	return hash
+}
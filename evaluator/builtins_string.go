@@ -0,0 +1,420 @@
+package evaluator
+
+import (
+	"doge/object"
+	"strings"
+	"unicode/utf8"
+)
+
+// String positions returned and accepted by these builtins are byte offsets,
+// the same as used by `len` and string indexing.
+func initStringBuiltins() {
+	builtins["split"] = &object.Builtin{
+		Fn:            splitBuiltin,
+		Documentation: "This function splits a string at a separator, or at whitespace if none is given!",
+	}
+	builtins["join"] = &object.Builtin{
+		Fn:            joinBuiltin,
+		Documentation: "This function joins an array of strings with a separator!",
+	}
+	builtins["strip"] = &object.Builtin{
+		Fn:            stripBuiltin("strip", strings.TrimSpace, strings.Trim),
+		Documentation: "This function removes whitespace, or the given characters, from both ends of a string!",
+	}
+	builtins["lstrip"] = &object.Builtin{
+		Fn: stripBuiltin("lstrip", func(s string) string {
+			return strings.TrimLeft(s, " \t\r\n\v\f")
+		}, strings.TrimLeft),
+		Documentation: "This function removes whitespace, or the given characters, from the start of a string!",
+	}
+	builtins["rstrip"] = &object.Builtin{
+		Fn: stripBuiltin("rstrip", func(s string) string {
+			return strings.TrimRight(s, " \t\r\n\v\f")
+		}, strings.TrimRight),
+		Documentation: "This function removes whitespace, or the given characters, from the end of a string!",
+	}
+	builtins["trim_prefix"] = &object.Builtin{
+		Fn:            stringPairBuiltin("trim_prefix", func(s, affix string) object.Object { return &object.String{Value: strings.TrimPrefix(s, affix)} }),
+		Documentation: "This function removes a prefix from a string if it is present!",
+	}
+	builtins["trim_suffix"] = &object.Builtin{
+		Fn:            stringPairBuiltin("trim_suffix", func(s, affix string) object.Object { return &object.String{Value: strings.TrimSuffix(s, affix)} }),
+		Documentation: "This function removes a suffix from a string if it is present!",
+	}
+	builtins["replace"] = &object.Builtin{
+		Fn:            replaceBuiltin,
+		Documentation: "This function replaces occurrences of a substring, optionally only the first n!",
+	}
+	builtins["upper"] = &object.Builtin{
+		Fn:            stringMapBuiltin("upper", strings.ToUpper),
+		Documentation: "This function converts a string to upper case!",
+	}
+	builtins["lower"] = &object.Builtin{
+		Fn:            stringMapBuiltin("lower", strings.ToLower),
+		Documentation: "This function converts a string to lower case!",
+	}
+	builtins["startswith"] = &object.Builtin{
+		Fn:            stringPairBuiltin("startswith", func(s, affix string) object.Object { return NativeBoolToBooleanObject(strings.HasPrefix(s, affix)) }),
+		Documentation: "This function checks if a string starts with a prefix!",
+	}
+	builtins["endswith"] = &object.Builtin{
+		Fn:            stringPairBuiltin("endswith", func(s, affix string) object.Object { return NativeBoolToBooleanObject(strings.HasSuffix(s, affix)) }),
+		Documentation: "This function checks if a string ends with a suffix!",
+	}
+	builtins["find"] = &object.Builtin{
+		Fn:            stringPairBuiltin("find", func(s, sub string) object.Object { return &object.Integer{Value: int64(strings.Index(s, sub))} }),
+		Documentation: "This function returns the position of a substring, or -1 if it is not found!",
+	}
+	builtins["index"] = &object.Builtin{
+		Fn: stringPairBuiltin("index", func(s, sub string) object.Object {
+			idx := strings.Index(s, sub)
+			if idx < 0 {
+				return NewError("substring not found: %q", sub)
+			}
+			return &object.Integer{Value: int64(idx)}
+		}),
+		Documentation: "This function returns the position of a substring, or an error if it is not found!",
+	}
+	builtins["count"] = &object.Builtin{
+		Fn:            stringPairBuiltin("count", func(s, sub string) object.Object { return &object.Integer{Value: int64(strings.Count(s, sub))} }),
+		Documentation: "This function counts the non-overlapping occurrences of a substring!",
+	}
+	builtins["repeat"] = &object.Builtin{
+		Fn:            repeatBuiltin,
+		Documentation: "This function repeats a string n times!",
+	}
+	builtins["pad"] = &object.Builtin{
+		Fn:            padBuiltin,
+		Documentation: "This function pads a string to a width. Usage: pad(str, width, fill=\" \", align=\"left\"|\"right\"|\"center\")",
+	}
+	builtins["chars"] = &object.Builtin{
+		Fn:            charsBuiltin,
+		Documentation: "This function returns the characters of a string as an array!",
+	}
+	builtins["ord"] = &object.Builtin{
+		Fn:            ordBuiltin,
+		Documentation: "This function returns the code point of a single character!",
+	}
+	builtins["chr"] = &object.Builtin{
+		Fn:            chrBuiltin,
+		Documentation: "This function returns the character for a code point!",
+	}
+	builtins["reverse"] = &object.Builtin{
+		Fn:            reverseBuiltin,
+		Documentation: "This function reverses a string, or returns a reversed copy of an array!",
+	}
+}
+
+func stringMapBuiltin(name string, fn func(string) string) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 1, 1); err != nil {
+			return err
+		}
+		s, err := StringArgument(name, args, 0)
+		if err != nil {
+			return err
+		}
+
+		return &object.String{Value: fn(s)}
+	}
+}
+
+func stringPairBuiltin(name string, fn func(string, string) object.Object) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 2, 2); err != nil {
+			return err
+		}
+		s, err := StringArgument(name, args, 0)
+		if err != nil {
+			return err
+		}
+		other, err := StringArgument(name, args, 1)
+		if err != nil {
+			return err
+		}
+
+		return fn(s, other)
+	}
+}
+
+func stripBuiltin(name string, space func(string) string, cutset func(string, string) string) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 1, 2); err != nil {
+			return err
+		}
+		s, err := StringArgument(name, args, 0)
+		if err != nil {
+			return err
+		}
+		if len(args) == 1 {
+			return &object.String{Value: space(s)}
+		}
+
+		chars, err := StringArgument(name, args, 1)
+		if err != nil {
+			return err
+		}
+
+		return &object.String{Value: cutset(s, chars)}
+	}
+}
+
+func splitBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	s, err := StringArgument("split", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var parts []string
+	if len(args) == 1 {
+		parts = strings.Fields(s)
+	} else {
+		sep, err := StringArgument("split", args, 1)
+		if err != nil {
+			return err
+		}
+		if sep == "" {
+			return NewError("separator for `split` must not be empty")
+		}
+		parts = strings.Split(s, sep)
+	}
+
+	return StringsToArray(parts)
+}
+
+func joinBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return ArgumentTypeError("join", 0, "ARRAY", args[0])
+	}
+
+	sep := ""
+	if len(args) == 2 {
+		var err *object.Error
+		if sep, err = StringArgument("join", args, 1); err != nil {
+			return err
+		}
+	}
+
+	parts := make([]string, len(arr.Elements))
+	for i, el := range arr.Elements {
+		str, ok := el.(*object.String)
+		if !ok {
+			return NewError("element %d of array passed to `join` must be STRING, got %s", i, el.Type())
+		}
+		parts[i] = str.Value
+	}
+
+	return &object.String{Value: strings.Join(parts, sep)}
+}
+
+func replaceBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 3, 4); err != nil {
+		return err
+	}
+
+	var strs [3]string
+	for i := range strs {
+		s, err := StringArgument("replace", args, i)
+		if err != nil {
+			return err
+		}
+		strs[i] = s
+	}
+
+	n := int64(-1)
+	if len(args) == 4 {
+		var err *object.Error
+		if n, err = IntegerArgument("replace", args, 3); err != nil {
+			return err
+		}
+	}
+
+	return &object.String{Value: strings.Replace(strs[0], strs[1], strs[2], int(n))}
+}
+
+func repeatBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	s, err := StringArgument("repeat", args, 0)
+	if err != nil {
+		return err
+	}
+	n, err := IntegerArgument("repeat", args, 1)
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return NewError("negative repeat count: %d", n)
+	}
+
+	repeated, err := RepeatString("repeat", s, n)
+	if err != nil {
+		return err
+	}
+
+	return &object.String{Value: repeated}
+}
+
+// MaxStringLength is the length in bytes of the longest string that repeat
+// and pad build.
+const MaxStringLength = 1 << 30
+
+// RepeatString is strings.Repeat, returning an error instead of building a
+// string longer than MaxStringLength.
+func RepeatString(name, s string, n int64) (string, *object.Error) {
+	if n > 0 && int64(len(s)) > MaxStringLength/n {
+		return "", NewError("result of `%s` would be longer than %d bytes", name, MaxStringLength)
+	}
+
+	return strings.Repeat(s, int(n)), nil
+}
+
+func padBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 4); err != nil {
+		return err
+	}
+	s, err := StringArgument("pad", args, 0)
+	if err != nil {
+		return err
+	}
+	width, err := IntegerArgument("pad", args, 1)
+	if err != nil {
+		return err
+	}
+
+	fill := " "
+	if len(args) > 2 {
+		if fill, err = StringArgument("pad", args, 2); err != nil {
+			return err
+		}
+		if utf8.RuneCountInString(fill) != 1 {
+			return NewError("fill for `pad` must be a single character, got %q", fill)
+		}
+	}
+
+	align := "left"
+	if len(args) > 3 {
+		if align, err = StringArgument("pad", args, 3); err != nil {
+			return err
+		}
+	}
+
+	padding := int64(0)
+	if length := int64(utf8.RuneCountInString(s)); width > length {
+		padding = width - length
+	}
+
+	var before, after int64
+	switch align {
+	case "left":
+		after = padding
+	case "right":
+		before = padding
+	case "center":
+		before, after = padding/2, padding-padding/2
+	default:
+		return NewError("alignment for `pad` must be \"left\", \"right\" or \"center\", got %q", align)
+	}
+
+	left, err := RepeatString("pad", fill, before)
+	if err != nil {
+		return err
+	}
+	right, err := RepeatString("pad", fill, after)
+	if err != nil {
+		return err
+	}
+
+	return &object.String{Value: left + s + right}
+}
+
+func charsBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	s, err := StringArgument("chars", args, 0)
+	if err != nil {
+		return err
+	}
+
+	chars := []string{}
+	for _, r := range s {
+		chars = append(chars, string(r))
+	}
+
+	return StringsToArray(chars)
+}
+
+func ordBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	s, err := StringArgument("ord", args, 0)
+	if err != nil {
+		return err
+	}
+	if utf8.RuneCountInString(s) != 1 {
+		return NewError("argument to `ord` must be a single character, got %q", s)
+	}
+
+	r, _ := utf8.DecodeRuneInString(s)
+
+	return &object.Integer{Value: int64(r)}
+}
+
+func chrBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	code, err := IntegerArgument("chr", args, 0)
+	if err != nil {
+		return err
+	}
+	if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+		return NewError("invalid code point for `chr`: %d", code)
+	}
+
+	return &object.String{Value: string(rune(code))}
+}
+
+func reverseBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	if arr, ok := args[0].(*object.Array); ok {
+		elements := make([]object.Object, len(arr.Elements))
+		for i, el := range arr.Elements {
+			elements[len(elements)-1-i] = el
+		}
+		return &object.Array{Elements: elements}
+	}
+
+	s, ok := args[0].(*object.String)
+	if !ok {
+		return NewError("argument to `reverse` must be STRING or ARRAY, got %s", args[0].Type())
+	}
+
+	runes := []rune(s.Value)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return &object.String{Value: string(runes)}
+}
+
+func StringsToArray(strs []string) *object.Array {
+	elements := make([]object.Object, len(strs))
+	for i, s := range strs {
+		elements[i] = &object.String{Value: s}
+	}
+
+	return &object.Array{Elements: elements}
+}
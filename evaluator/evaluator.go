@@ -5,6 +5,8 @@ import (
 	"doge/object"
 	"fmt"
 	"math"
+	"strings"
+	"time"
 )
 
 var (
@@ -23,9 +25,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return Eval(node.Expression, env)
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return EvalInterpolatedString(node, env)
 	case *ast.Identifier:
 		return EvalIdentifier(node, env)
 	case *ast.InfixExpression:
+		if node.Operator == "|>" {
+			return EvalPipeExpression(node, env)
+		}
+
 		left := Eval(node.Left, env)
 		if IsError(left) {
 			return left
@@ -37,17 +45,27 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 
 		return EvalInfixExpression(node.Operator, left, right)
 	case *ast.AssignExpression:
-		idt, ok := node.Left.(*ast.Identifier)
-		if !ok {
-			return NewError("cannot assign to non identifier!")
-		}
-
 		right := Eval(node.Right, env)
 		if IsError(right) {
 			return right
 		}
 
-		return EvalAssignExpression(node.TokenLiteral(), idt, right, env)
+		switch left := node.Left.(type) {
+		case *ast.Identifier:
+			return EvalAssignExpression(node.TokenLiteral(), left, right, env)
+		case *ast.ArrayLiteral, *ast.HashLiteral:
+			if node.TokenLiteral() != "=" {
+				return NewError("cannot use %s with destructuring assignment", node.TokenLiteral())
+			}
+
+			if err := Destructure(left, right, env); err != nil {
+				return err
+			}
+
+			return right
+		default:
+			return NewError("cannot assign to non identifier!")
+		}
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if IsError(right) {
@@ -60,10 +78,24 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return &object.Function{Parameters: params, Body: body}
 	case *ast.IfExpression:
 		return EvalIfExpression(node, env)
+	case *ast.ConditionalExpression:
+		condition := Eval(node.Condition, env)
+		if IsError(condition) {
+			return condition
+		}
+
+		if IsTruthy(condition) {
+			return Eval(node.Consequence, env)
+		}
+		return Eval(node.Alternative, env)
 	case *ast.WhileExpression:
 		return EvalWhileExpression(node, env)
 	case *ast.ForExpression:
 		return EvalForExpression(node, env)
+	case *ast.ForInExpression:
+		return EvalForInExpression(node, env)
+	case *ast.MatchExpression:
+		return EvalMatchExpression(node, env)
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
 		if IsError(val) {
@@ -110,11 +142,69 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 
 		return EvalIndexExpression(left, index)
+	case *ast.MemberExpression:
+		obj := Eval(node.Object, env)
+		if IsError(obj) {
+			return obj
+		}
+
+		return EvalMemberExpression(obj, node.Member.Value)
 	}
 
 	return nil
 }
 
+func EvalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+
+	for i, part := range node.Parts {
+		value := Eval(part, env)
+		if IsError(value) {
+			return value
+		}
+
+		formatted, err := FormatObject(value, node.Specs[i])
+		if err != nil {
+			return err
+		}
+
+		out.WriteString(formatted)
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+// EvalPipeExpression passes the left value as the first argument to the call
+// on the right, so `x |> f(a)` is evaluated as `f(x, a)` and `x |> f` as
+// `f(x)`.
+func EvalPipeExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if IsError(left) {
+		return left
+	}
+
+	if call, ok := node.Right.(*ast.CallExpression); ok {
+		function := Eval(call.Function, env)
+		if IsError(function) {
+			return function
+		}
+
+		args := EvalExpressions(call.Arguments, env)
+		if len(args) == 1 && IsError(args[0]) {
+			return args[0]
+		}
+
+		return ApplyFunction(function, append([]object.Object{left}, args...), env)
+	}
+
+	function := Eval(node.Right, env)
+	if IsError(function) {
+		return function
+	}
+
+	return ApplyFunction(function, []object.Object{left}, env)
+}
+
 func EvalAssignExpression(literal string, idt *ast.Identifier, right object.Object, env *object.Environment) object.Object {
 	val, ok := env.Get(idt.Value)
 
@@ -171,33 +261,87 @@ func EvalAssignExpression(literal string, idt *ast.Identifier, right object.Obje
 	}
 
 	env.Set(idt.Value, result)
-	return NULL
+	return result
+}
+
+// Destructure binds the parts of value to the identifiers in pattern. Array
+// patterns need an array of the same length, hash patterns a hash that
+// contains every key. The identifier `_` discards its value.
+func Destructure(pattern ast.Expression, value object.Object, env *object.Environment) *object.Error {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			env.Set(pattern.Value, value)
+		}
+	case *ast.ArrayLiteral:
+		arr, ok := value.(*object.Array)
+		if !ok {
+			return NewError("cannot destructure %s into array pattern %s", value.Type(), pattern.String())
+		}
+
+		if len(arr.Elements) != len(pattern.Elements) {
+			return NewError("cannot destructure array of length %d into %d targets", len(arr.Elements), len(pattern.Elements))
+		}
+
+		for i, target := range pattern.Elements {
+			if err := Destructure(target, arr.Elements[i], env); err != nil {
+				return err
+			}
+		}
+	case *ast.HashLiteral:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return NewError("cannot destructure %s into hash pattern %s", value.Type(), pattern.String())
+		}
+
+		for _, keyNode := range pattern.Keys {
+			key := Eval(keyNode, env)
+			if IsError(key) {
+				return key.(*object.Error)
+			}
+
+			if !object.IsHashable(key) {
+				return NewError("unusable as hash key: %s", key.Type())
+			}
+
+			elm, ok := hash.Get(key)
+			if !ok {
+				return NewError("cannot destructure hash: missing key %s", key.Inspect())
+			}
+
+			if err := Destructure(pattern.Pairs[keyNode], elm, env); err != nil {
+				return err
+			}
+		}
+	default:
+		return NewError("invalid assignment target: %s", pattern.String())
+	}
+
+	return nil
 }
 
 func EvalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
+	for _, keyNode := range node.Keys {
 		key := Eval(keyNode, env)
 		if IsError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
+		if !object.IsHashable(key) {
 			return NewError("unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(node.Pairs[keyNode], env)
 		if IsError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(key, value)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func EvalIndexExpression(left, index object.Object) object.Object {
@@ -228,19 +372,41 @@ func EvalStringIndexExpression(left object.Object, index object.Object) object.O
 	return &object.String{Value: fmt.Sprintf("%c", strObj.Value[idx.Value])}
 }
 
+func EvalMemberExpression(obj object.Object, name string) object.Object {
+	switch obj := obj.(type) {
+	case *object.Module:
+		member, ok := obj.Members[name]
+		if !ok {
+			return NewError("module %s has no member %s", obj.Name, name)
+		}
+		return member
+	case *object.Hash:
+		return EvalHashIndexExpression(obj, &object.String{Value: name})
+	case *object.Time:
+		if member := TimeMember(obj.Value, name); member != nil {
+			return member
+		}
+	case *object.Duration:
+		if member := DurationMember(obj.Value, name); member != nil {
+			return member
+		}
+	}
+
+	return NewError("cannot access member %s of %s", name, obj.Type())
+}
+
 func EvalHashIndexExpression(left, index object.Object) object.Object {
 	hashObj := left.(*object.Hash)
-	key, ok := index.(object.Hashable)
-	if !ok {
+	if !object.IsHashable(index) {
 		return NewError("unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hashObj.Pairs[key.HashKey()]
+	value, ok := hashObj.Get(index)
 	if !ok {
 		return NULL
 	}
 
-	return pair.Value
+	return value
 }
 
 func EvalArrayIndexExpression(array, index object.Object) object.Object {
@@ -276,7 +442,10 @@ func EvalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 func ApplyFunction(fn object.Object, args []object.Object, env *object.Environment) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		return RunFunction(fn, args)
+		if len(args) < len(fn.Parameters) {
+			return NewError("wrong number of arguments. got=%d, want=%d", len(args), len(fn.Parameters))
+		}
+		return RunFunction(fn, args, env)
 	case *object.Builtin:
 		return fn.Fn(env, args...)
 	default:
@@ -284,14 +453,14 @@ func ApplyFunction(fn object.Object, args []object.Object, env *object.Environme
 	}
 }
 
-func RunFunction(fn *object.Function, args []object.Object) object.Object {
-	extendedEnv := ExtendFunctionEnv(fn, args)
+func RunFunction(fn *object.Function, args []object.Object, env *object.Environment) object.Object {
+	extendedEnv := ExtendFunctionEnv(fn, args, env)
 	evaluated := Eval(fn.Body, extendedEnv)
 	return UnwrapReturnValue(evaluated)
 }
 
-func ExtendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
-	env := object.NewEnvironment()
+func ExtendFunctionEnv(fn *object.Function, args []object.Object, caller *object.Environment) *object.Environment {
+	env := object.NewFunctionEnvironment(caller)
 
 	for paramIdx, param := range fn.Parameters {
 		env.Set(param.Value, args[paramIdx])
@@ -325,8 +494,10 @@ func EvalProgram(program *ast.Program, env *object.Environment) object.Object {
 	return result
 }
 
+// EvalBlockStatements returns the value of the last statement in the block,
+// or null if the block is empty.
 func EvalBlockStatements(block *ast.BlockStatement, env *object.Environment) object.Object {
-	var result object.Object
+	var result object.Object = NULL
 
 	for _, stmt := range block.Statements {
 		result = Eval(stmt, env)
@@ -355,6 +526,14 @@ func EvalPrefixExpression(operator string, right object.Object) object.Object {
 
 func EvalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
+	case operator == "in":
+		return EvalInExpression(left, right)
+	case operator == "not in":
+		result := EvalInExpression(left, right)
+		if IsError(result) {
+			return result
+		}
+		return EvalBangOperatorExpression(result)
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return EvalIntegerInfixExpression(operator, left, right)
 	case left.Type() == object.FLOAT_OBJ && right.Type() == object.FLOAT_OBJ:
@@ -363,10 +542,16 @@ func EvalInfixExpression(operator string, left, right object.Object) object.Obje
 		return EvalMixedInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return EvalStringInfixExpression(operator, left, right)
+	case left.Type() == object.ARRAY_OBJ && right.Type() == object.ARRAY_OBJ:
+		return EvalArrayInfixExpression(operator, left, right)
+	case left.Type() == object.SET_OBJ && right.Type() == object.SET_OBJ:
+		return EvalSetInfixExpression(operator, left, right)
+	case IsTimeValue(left) || IsTimeValue(right):
+		return EvalTimeInfixExpression(operator, left, right)
 	case operator == "==":
-		return NativeBoolToBooleanObject(left == right)
+		return NativeBoolToBooleanObject(object.Equal(left, right))
 	case operator == "!=":
-		return NativeBoolToBooleanObject(left != right)
+		return NativeBoolToBooleanObject(!object.Equal(left, right))
 	case operator == "&&":
 		leftVal := left == TRUE
 		rightVal := right == TRUE
@@ -412,9 +597,9 @@ func EvalMixedInfixExpression(operator string, left, right object.Object) object
 	case ">=":
 		return NativeBoolToBooleanObject(leftVal >= rightVal)
 	case "!=":
-		return NativeBoolToBooleanObject(leftVal != rightVal)
+		return NativeBoolToBooleanObject(!object.Equal(left, right))
 	case "==":
-		return NativeBoolToBooleanObject(leftVal == rightVal)
+		return NativeBoolToBooleanObject(object.Equal(left, right))
 	default:
 		return NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
@@ -436,6 +621,13 @@ func EvalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "/":
 		return &object.Integer{Value: leftVal / rightVal}
 	case "**":
+		if rightVal >= 0 {
+			result, ok := IntPow(leftVal, rightVal)
+			if !ok {
+				return NewError("integer overflow in `**`")
+			}
+			return &object.Integer{Value: result}
+		}
 		return &object.Integer{Value: int64(math.Pow(float64(leftVal), float64(rightVal)))}
 	case "&":
 		return &object.Integer{Value: leftVal & rightVal}
@@ -513,6 +705,14 @@ func EvalStringInfixExpression(operator string, left, right object.Object) objec
 	switch operator {
 	case "+":
 		return &object.String{Value: leftVal + rightVal}
+	case "<":
+		return NativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return NativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return NativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return NativeBoolToBooleanObject(leftVal >= rightVal)
 	case "!=":
 		return NativeBoolToBooleanObject(leftVal != rightVal)
 	case "==":
@@ -522,6 +722,220 @@ func EvalStringInfixExpression(operator string, left, right object.Object) objec
 	}
 }
 
+func EvalArrayInfixExpression(operator string, left, right object.Object) object.Object {
+	switch operator {
+	case "==":
+		return NativeBoolToBooleanObject(object.Equal(left, right))
+	case "!=":
+		return NativeBoolToBooleanObject(!object.Equal(left, right))
+	case "<", ">", "<=", ">=":
+		result, ok := object.Compare(left, right)
+		if !ok {
+			return NewError("cannot compare elements of %s and %s", left.Inspect(), right.Inspect())
+		}
+
+		return EvalComparison(operator, result)
+	default:
+		return NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func EvalSetInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Set)
+	rightVal := right.(*object.Set)
+	result := object.NewSet()
+
+	switch operator {
+	case "|":
+		for _, e := range leftVal.Elements() {
+			result.Add(e)
+		}
+		for _, e := range rightVal.Elements() {
+			result.Add(e)
+		}
+	case "&":
+		for _, e := range leftVal.Elements() {
+			if rightVal.Contains(e) {
+				result.Add(e)
+			}
+		}
+	case "-":
+		for _, e := range leftVal.Elements() {
+			if !rightVal.Contains(e) {
+				result.Add(e)
+			}
+		}
+	case "^":
+		for _, e := range leftVal.Elements() {
+			if !rightVal.Contains(e) {
+				result.Add(e)
+			}
+		}
+		for _, e := range rightVal.Elements() {
+			if !leftVal.Contains(e) {
+				result.Add(e)
+			}
+		}
+	case "<=":
+		return NativeBoolToBooleanObject(IsSubset(leftVal, rightVal))
+	case ">=":
+		return NativeBoolToBooleanObject(IsSubset(rightVal, leftVal))
+	case "<":
+		return NativeBoolToBooleanObject(leftVal.Len() < rightVal.Len() && IsSubset(leftVal, rightVal))
+	case ">":
+		return NativeBoolToBooleanObject(leftVal.Len() > rightVal.Len() && IsSubset(rightVal, leftVal))
+	case "==":
+		return NativeBoolToBooleanObject(object.Equal(left, right))
+	case "!=":
+		return NativeBoolToBooleanObject(!object.Equal(left, right))
+	default:
+		return NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	return result
+}
+
+func IsSubset(sub, set *object.Set) bool {
+	for _, e := range sub.Elements() {
+		if !set.Contains(e) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EvalInExpression tests membership: elements of arrays and sets, keys of
+// hashes, substrings of strings and elements of any other iterable.
+func EvalInExpression(left, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Set:
+		if !object.IsHashable(left) {
+			return FALSE
+		}
+		return NativeBoolToBooleanObject(right.Contains(left))
+	case *object.Hash:
+		if !object.IsHashable(left) {
+			return FALSE
+		}
+		_, ok := right.Get(left)
+		return NativeBoolToBooleanObject(ok)
+	case *object.String:
+		str, ok := left.(*object.String)
+		if !ok {
+			return NewError("left operand of `in` must be STRING, got %s", left.Type())
+		}
+		return NativeBoolToBooleanObject(strings.Contains(right.Value, str.Value))
+	case *object.Array, *object.Iterator:
+	default:
+		return NewError("operator `in` not supported: %s", right.Type())
+	}
+
+	found := false
+	err := Iterate(right, func(elm object.Object) bool {
+		found = object.Equal(left, elm)
+		return !found
+	})
+	if err != nil {
+		return err
+	}
+
+	return NativeBoolToBooleanObject(found)
+}
+
+func IsTimeValue(obj object.Object) bool {
+	return obj.Type() == object.TIME_OBJ || obj.Type() == object.DURATION_OBJ
+}
+
+// EvalTimeInfixExpression implements the arithmetic of times and durations:
+// a time plus or minus a duration is a time, the difference of two times is a
+// duration, and durations can be added, scaled and divided.
+func EvalTimeInfixExpression(operator string, left, right object.Object) object.Object {
+	switch operator {
+	case "==":
+		return NativeBoolToBooleanObject(object.Equal(left, right))
+	case "!=":
+		return NativeBoolToBooleanObject(!object.Equal(left, right))
+	case "<", ">", "<=", ">=":
+		result, ok := object.Compare(left, right)
+		if !ok {
+			return NewError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		}
+		return EvalComparison(operator, result)
+	}
+
+	switch l := left.(type) {
+	case *object.Time:
+		switch r := right.(type) {
+		case *object.Duration:
+			if operator == "+" {
+				return &object.Time{Value: l.Value.Add(r.Value)}
+			}
+			if operator == "-" {
+				return &object.Time{Value: l.Value.Add(-r.Value)}
+			}
+		case *object.Time:
+			if operator == "-" {
+				return &object.Duration{Value: l.Value.Sub(r.Value)}
+			}
+		}
+	case *object.Duration:
+		switch r := right.(type) {
+		case *object.Duration:
+			switch operator {
+			case "+":
+				return &object.Duration{Value: l.Value + r.Value}
+			case "-":
+				return &object.Duration{Value: l.Value - r.Value}
+			case "/":
+				if r.Value == 0 {
+					return NewError("division by zero")
+				}
+				return &object.Float{Value: float64(l.Value) / float64(r.Value)}
+			}
+		case *object.Time:
+			if operator == "+" {
+				return &object.Time{Value: r.Value.Add(l.Value)}
+			}
+		case *object.Integer, *object.Float:
+			factor := ObjectToFloat(r)
+			if operator == "*" {
+				return &object.Duration{Value: time.Duration(float64(l.Value) * factor)}
+			}
+			if operator == "/" {
+				if factor == 0 {
+					return NewError("division by zero")
+				}
+				return &object.Duration{Value: time.Duration(float64(l.Value) / factor)}
+			}
+		}
+	case *object.Integer, *object.Float:
+		if r, ok := right.(*object.Duration); ok && operator == "*" {
+			return &object.Duration{Value: time.Duration(ObjectToFloat(l) * float64(r.Value))}
+		}
+	}
+
+	if left.Type() != right.Type() {
+		return NewError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	}
+	return NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+func EvalComparison(operator string, result int) object.Object {
+	switch operator {
+	case "<":
+		return NativeBoolToBooleanObject(result < 0)
+	case ">":
+		return NativeBoolToBooleanObject(result > 0)
+	case "<=":
+		return NativeBoolToBooleanObject(result <= 0)
+	default:
+		return NativeBoolToBooleanObject(result >= 0)
+	}
+}
+
+// EvalIfExpression returns the value of the branch that was taken, or null
+// if the condition is false and there is no else branch.
 func EvalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	pEnv := object.NewPartiallyEnclosedEnvironment(env)
 	condition := Eval(ie.Condition, pEnv)
@@ -538,9 +952,10 @@ func EvalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	}
 }
 
+// EvalWhileExpression runs the loop for its side effects. Like every other
+// loop it evaluates to null.
 func EvalWhileExpression(we *ast.WhileExpression, env *object.Environment) object.Object {
 	pEnv := object.NewPartiallyEnclosedEnvironment(env)
-	var evaluated object.Object
 
 	condition := Eval(we.Condition, pEnv)
 	if IsError(condition) {
@@ -548,7 +963,7 @@ func EvalWhileExpression(we *ast.WhileExpression, env *object.Environment) objec
 	}
 
 	for IsTruthy(condition) {
-		evaluated = Eval(we.Consequence, pEnv)
+		evaluated := Eval(we.Consequence, pEnv)
 		if IsError(evaluated) || evaluated.Type() == object.RETURN_VALUE_OBJ {
 			return evaluated
 		}
@@ -563,18 +978,16 @@ func EvalWhileExpression(we *ast.WhileExpression, env *object.Environment) objec
 		}
 	}
 
-	if evaluated != nil {
-		return evaluated
-	}
-
 	return NULL
 }
 
+// EvalForExpression runs a C-style loop. Like in while loops, the body
+// assigns to variables of the enclosing scopes, but a variable set by the
+// initializer belongs to the loop and shadows one of the same name outside.
 func EvalForExpression(fe *ast.ForExpression, env *object.Environment) object.Object {
-	pEnv := object.NewEnclosedEnvironment(env)
-	var evaluated object.Object
+	pEnv := object.NewPartiallyEnclosedEnvironment(env)
 
-	initial := Eval(fe.Initial, pEnv)
+	initial := EvalLoopInitializer(fe.Initial, pEnv)
 	if IsError(initial) {
 		return initial
 	}
@@ -585,7 +998,7 @@ func EvalForExpression(fe *ast.ForExpression, env *object.Environment) object.Ob
 	}
 
 	for IsTruthy(condition) {
-		evaluated = Eval(fe.Consequence, pEnv)
+		evaluated := Eval(fe.Consequence, pEnv)
 		if IsError(evaluated) || evaluated.Type() == object.RETURN_VALUE_OBJ {
 			return evaluated
 		}
@@ -605,11 +1018,260 @@ func EvalForExpression(fe *ast.ForExpression, env *object.Environment) object.Ob
 		}
 	}
 
-	if evaluated != nil {
-		return evaluated
+	return NULL
+}
+
+func EvalLoopInitializer(node ast.Expression, env *object.Environment) object.Object {
+	assign, ok := node.(*ast.AssignExpression)
+	if !ok || assign.TokenLiteral() != "=" {
+		return Eval(node, env)
+	}
+	ident, ok := assign.Left.(*ast.Identifier)
+	if !ok {
+		return Eval(node, env)
 	}
 
-	return NULL
+	value := Eval(assign.Right, env)
+	if IsError(value) {
+		return value
+	}
+
+	return env.SetLocal(ident.Value, value)
+}
+
+func EvalForInExpression(fe *ast.ForInExpression, env *object.Environment) object.Object {
+	pEnv := object.NewPartiallyEnclosedEnvironment(env)
+
+	iterable := Eval(fe.Iterable, pEnv)
+	if IsError(iterable) {
+		return iterable
+	}
+
+	var result object.Object = NULL
+	err := Iterate(iterable, func(elm object.Object) bool {
+		pEnv.SetLocal(fe.Variable.Value, elm)
+
+		evaluated := Eval(fe.Consequence, pEnv)
+		if evaluated == nil {
+			return true
+		}
+
+		switch evaluated.Type() {
+		case object.ERROR_OBJ, object.RETURN_VALUE_OBJ:
+			result = evaluated
+			return false
+		case object.BREAK_OBJ:
+			return false
+		}
+
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return result
+}
+
+// IsIterable reports whether Iterate accepts obj.
+func IsIterable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Array, *object.String, *object.Hash, *object.Set, *object.Iterator:
+		return true
+	default:
+		return false
+	}
+}
+
+// Iterate calls fn for every element of an iterable object until fn returns
+// false. Strings yield their characters, hashes their keys.
+func Iterate(obj object.Object, fn func(object.Object) bool) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Array:
+		for _, elm := range obj.Elements {
+			if !fn(elm) {
+				return nil
+			}
+		}
+	case *object.String:
+		for _, ch := range obj.Value {
+			if !fn(&object.String{Value: string(ch)}) {
+				return nil
+			}
+		}
+	case *object.Hash:
+		for _, pair := range obj.Ordered() {
+			if !fn(pair.Key) {
+				return nil
+			}
+		}
+	case *object.Set:
+		for _, elm := range obj.Elements() {
+			if !fn(elm) {
+				return nil
+			}
+		}
+	case *object.Iterator:
+		for {
+			elm, ok := obj.Next()
+			if !ok {
+				return nil
+			}
+			if err, isErr := elm.(*object.Error); isErr {
+				return err
+			}
+			if !fn(elm) {
+				if obj.Close != nil {
+					obj.Close()
+				}
+				return nil
+			}
+		}
+	default:
+		return NewError("object is not iterable: %s", obj.Type())
+	}
+
+	return nil
+}
+
+// typePatterns maps the names usable in type patterns like `case int(n)` to
+// the object types they match.
+var typePatterns = map[string][]object.ObjectType{
+	"int":      {object.INTEGER_OBJ},
+	"float":    {object.FLOAT_OBJ},
+	"string":   {object.STRING_OBJ},
+	"bool":     {object.BOOLEAN_OBJ},
+	"array":    {object.ARRAY_OBJ},
+	"hash":     {object.HASH_OBJ},
+	"set":      {object.SET_OBJ},
+	"null":     {object.NULL_OBJ},
+	"function": {object.FUNCTION_OBJ, object.BUILTIN_OBJ},
+	"iterator": {object.ITERATOR_OBJ},
+	"time":     {object.TIME_OBJ},
+	"duration": {object.DURATION_OBJ},
+}
+
+func EvalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+	value := Eval(me.Value, env)
+	if IsError(value) {
+		return value
+	}
+
+	for _, matchCase := range me.Cases {
+		caseEnv := object.NewPartiallyEnclosedEnvironment(env)
+
+		matched, err := MatchPattern(matchCase.Pattern, value, caseEnv)
+		if err != nil {
+			return err
+		}
+
+		if !matched {
+			continue
+		}
+
+		if matchCase.Guard != nil {
+			guard := Eval(matchCase.Guard, caseEnv)
+			if IsError(guard) {
+				return guard
+			}
+
+			if !IsTruthy(guard) {
+				continue
+			}
+		}
+
+		return Eval(matchCase.Body, caseEnv)
+	}
+
+	return NewError("non-exhaustive match: no case matched %s", value.Inspect())
+}
+
+// MatchPattern reports whether value has the shape described by pattern and
+// binds the identifiers of the pattern in env.
+func MatchPattern(pattern ast.Expression, value object.Object, env *object.Environment) (bool, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			env.SetLocal(pattern.Value, value)
+		}
+		return true, nil
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean, *ast.PrefixExpression:
+		if prefix, ok := pattern.(*ast.PrefixExpression); ok && prefix.Operator != "-" {
+			return false, NewError("invalid pattern: %s", pattern.String())
+		}
+
+		literal := Eval(pattern, env)
+		if IsError(literal) {
+			return false, literal.(*object.Error)
+		}
+
+		return object.Equal(literal, value), nil
+	case *ast.ArrayLiteral:
+		arr, ok := value.(*object.Array)
+		if !ok || len(arr.Elements) != len(pattern.Elements) {
+			return false, nil
+		}
+
+		for i, elm := range pattern.Elements {
+			matched, err := MatchPattern(elm, arr.Elements[i], env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+
+		return true, nil
+	case *ast.HashLiteral:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+
+		for _, keyNode := range pattern.Keys {
+			key := Eval(keyNode, env)
+			if IsError(key) {
+				return false, key.(*object.Error)
+			}
+
+			if !object.IsHashable(key) {
+				return false, NewError("unusable as hash key: %s", key.Type())
+			}
+
+			elm, ok := hash.Get(key)
+			if !ok {
+				return false, nil
+			}
+
+			matched, err := MatchPattern(pattern.Pairs[keyNode], elm, env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+
+		return true, nil
+	case *ast.CallExpression:
+		name, ok := pattern.Function.(*ast.Identifier)
+		if !ok || len(pattern.Arguments) > 1 {
+			return false, NewError("invalid pattern: %s", pattern.String())
+		}
+
+		types, ok := typePatterns[name.Value]
+		if !ok {
+			return false, NewError("unknown type in pattern: %s", name.Value)
+		}
+
+		for _, t := range types {
+			if value.Type() == t {
+				if len(pattern.Arguments) == 0 {
+					return true, nil
+				}
+				return MatchPattern(pattern.Arguments[0], value, env)
+			}
+		}
+
+		return false, nil
+	default:
+		return false, NewError("invalid pattern: %s", pattern.String())
+	}
 }
 
 func EvalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
@@ -621,6 +1283,13 @@ func EvalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return builtin
 	}
 
+	if module, ok := modules[node.Value]; ok {
+		if env.Context().DisabledModules[node.Value] {
+			return NewError("module %s is disabled", node.Value)
+		}
+		return module
+	}
+
 	return NewError("identifier not found: %s", node.Value)
 }
 
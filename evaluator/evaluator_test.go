@@ -1,11 +1,20 @@
 package evaluator
 
 import (
+	"bufio"
+	"bytes"
 	"doge/lexer"
 	"doge/object"
 	"doge/parser"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func EvalTest(input string) object.Object {
@@ -13,10 +22,25 @@ func EvalTest(input string) object.Object {
 	p := parser.New(l)
 	program := p.ParseProgram()
 	env := object.NewEnvironment()
+	InitBuiltins()
 
 	return Eval(program, env)
 }
 
+func EvalTestWithOutput(input string) (object.Object, string, string) {
+	var stdout, stderr bytes.Buffer
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	env.Context().Stdout = &stdout
+	env.Context().Stderr = &stderr
+	InitBuiltins()
+
+	return Eval(program, env), stdout.String(), stderr.String()
+}
+
 func IntegerObjectTest(t *testing.T, obj object.Object, expected int64) bool {
 	result, ok := obj.(*object.Integer)
 	if !ok {
@@ -53,6 +77,76 @@ func BooleanObjectTest(t *testing.T, obj object.Object, expected bool) bool {
 	return true
 }
 
+// InspectObjectTest checks what obj looks like when printed. An expected
+// value starting with "ERROR: " must be an error with the rest as message.
+func InspectObjectTest(t *testing.T, input string, obj object.Object, expected string) bool {
+	t.Helper()
+
+	if strings.HasPrefix(expected, "ERROR: ") {
+		errObj, ok := obj.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error for %q. got=%T(%+v)", input, obj, obj)
+			return false
+		}
+		if message := strings.TrimPrefix(expected, "ERROR: "); errObj.Message != message {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", input, message, errObj.Message)
+			return false
+		}
+
+		return true
+	}
+
+	if obj == nil || obj.Inspect() != expected {
+		t.Errorf("wrong result for %q. expected=%q, got=%+v", input, expected, obj)
+		return false
+	}
+
+	return true
+}
+
+type inspectTest struct {
+	input    string
+	expected string
+}
+
+// InspectTests evaluates every input and checks the result with
+// InspectObjectTest.
+func InspectTests(t *testing.T, tests []inspectTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		InspectObjectTest(t, tt.input, EvalTest(tt.input), tt.expected)
+	}
+}
+
+// expectedTest expects an int, a bool, nil for NULL, or a string checked
+// with InspectObjectTest.
+type expectedTest struct {
+	input    string
+	expected interface{}
+}
+
+func ExpectedTests(t *testing.T, tests []expectedTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		evaluated := EvalTest(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			IntegerObjectTest(t, evaluated, int64(expected))
+		case bool:
+			BooleanObjectTest(t, evaluated, expected)
+		case string:
+			InspectObjectTest(t, tt.input, evaluated, expected)
+		case nil:
+			NullObjectTest(t, evaluated)
+		default:
+			t.Fatalf("unsupported expected value %T for %q", expected, tt.input)
+		}
+	}
+}
+
 func TestEvalIntegerExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -435,3 +529,1179 @@ func TestHashIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, 2] == [1, 2, 3]", false},
+		{"[1, 2.0] == [1.0, 2]", true},
+		{`{"a": 1, "b": [1]} == {"b": [1], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} != {"b": 1}`, true},
+		{`[1] == {"a": 1}`, false},
+		{`"a" == 1`, false},
+		{`a = [1]; append(a, a); a == a`, true},
+		{`a = [1]; append(a, a); b = [1]; append(b, b); a == b`, true},
+		{`a = [1]; append(a, a); b = [2]; append(b, b); a == b`, false},
+		{`h = {}; update(h, {"s": h}); h == h`, true},
+		{`h = {}; update(h, {"s": h}); g = {}; update(g, {"s": g}); h == g`, true},
+		{`h = {}; update(h, {"s": h}); h == {"s": {}}`, false},
+	}
+
+	for _, tt := range tests {
+		evaluated := EvalTest(tt.input)
+		if !BooleanObjectTest(t, evaluated, tt.expected) {
+			t.Errorf("input: %s", tt.input)
+		}
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	tests := []expectedTest{
+		{`"a" < "b"`, true},
+		{`"b" < "a"`, false},
+		{`"abc" <= "abc"`, true},
+		{`"abd" > "abc"`, true},
+		{`"ab" >= "abc"`, false},
+		{"[1, 2] < [1, 3]", true},
+		{"[1, 2] < [1, 2, 0]", true},
+		{"[2] > [1, 9]", true},
+		{`[["a"], 1] <= [["a"], 1]`, true},
+		{`compare(1, 2)`, -1},
+		{`compare("b", "a")`, 1},
+		{`compare([1, 2.5], [1, 2.5])`, 0},
+		{`compare(1, "a")`, "ERROR: cannot compare INTEGER and STRING"},
+		{`[1] < ["a"]`, "ERROR: cannot compare elements of [1] and [a]"},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestHashableKeys(t *testing.T) {
+	tests := []expectedTest{
+		{`{[1, 2]: 5}[[1, 2]]`, 5},
+		{`{[1, 2]: 5}[[2, 1]]`, nil},
+		{`{[1, [2, 3]]: 5}[[1, [2, 3]]]`, 5},
+		{`{1.5: 5}[1.5]`, 5},
+		{`{1: 5}[1.0]`, 5},
+		{`{2.0: 5}[2]`, 5},
+		{`len({1: 1, 1.0: 2})`, 1},
+		{`{1: 1, 1.0: 2}[1]`, 2},
+		{`9007199254740993 == 9007199254740992.0`, false},
+		{`9007199254740992 == 9007199254740992.0`, true},
+		{`{9007199254740993: 1}[9007199254740992.0]`, nil},
+		{`{9007199254740992: 1}[9007199254740992.0]`, 1},
+		{`[9007199254740993] == [9007199254740992.0]`, false},
+		{`[1, 2] == freeze([1, 2])`, true},
+		{`{[1, {}]: 5}`, "ERROR: unusable as hash key: ARRAY"},
+		{`append(freeze([1]), 2)`, "ERROR: cannot modify frozen array"},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestHashKeysAreFrozen(t *testing.T) {
+	input := `
+key = [1, 2];
+h = {key: 5};
+append(key, 3);
+h[[1, 2]]`
+
+	IntegerObjectTest(t, EvalTest(input), 5)
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	input := `
+h = {"zeta": 1, "alpha": 2, 3: 3, "mid": 4, "alpha": 5};
+string(h)`
+
+	evaluated := EvalTest(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := "{zeta: 1, alpha: 5, 3: 3, mid: 4}"
+	if str.Value != expected {
+		t.Errorf("hash has wrong order. expected=%q, got=%q", expected, str.Value)
+	}
+}
+
+func TestHashLiteralEvaluationOrder(t *testing.T) {
+	input := `
+log = [];
+f = func(log, x) { append(log, x); x };
+h = {f(log, "a"): f(log, 1), f(log, "b"): f(log, 2), f(log, "c"): f(log, 3)};
+log`
+
+	evaluated := EvalTest(input)
+	arr, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if arr.Inspect() != "[a, 1, b, 2, c, 3]" {
+		t.Errorf("hash literal evaluated in wrong order. got=%s", arr.Inspect())
+	}
+}
+
+func TestSets(t *testing.T) {
+	tests := []expectedTest{
+		{`string(set([1, 2, 2, 3, 1]))`, "set(1, 2, 3)"},
+		{`string(set())`, "set()"},
+		{`string(set("abca"))`, "set(a, b, c)"},
+		{`len(set([1, 1.0, [1, 2], [1, 2]]))`, 2},
+		{`2 in set([1, 2])`, true},
+		{`5 in set([1, 2])`, false},
+		{`[1, 2] in set([[1, 2]])`, true},
+		{`{} in set([1])`, false},
+		{`string(set([1, 2]) | set([2, 3]))`, "set(1, 2, 3)"},
+		{`string(set([1, 2]) & set([2, 3]))`, "set(2)"},
+		{`string(set([1, 2]) - set([2, 3]))`, "set(1)"},
+		{`string(set([1, 2]) ^ set([2, 3]))`, "set(1, 3)"},
+		{`set([1, 2]) == set([2, 1])`, true},
+		{`set([1]) <= set([1, 2])`, true},
+		{`set([1, 2]) < set([1, 2])`, false},
+		{`s = 0; for (x in set([1, 2, 2, 3])) { s += x }; s`, 6},
+		{`set([{}])`, "ERROR: unusable as set element: HASH"},
+		{`set(1)`, "ERROR: argument to `set` must be iterable, got INTEGER"},
+		{`set(fs.lines("/"))`, "ERROR: `fs.lines` failed: read /: is a directory"},
+		{`string(set(process.lines("printf", ["a\nb\na\n"])))`, "set(a, b)"},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestForInExpression(t *testing.T) {
+	tests := []expectedTest{
+		{`s = 0; for (x in [1, 2, 3]) { s += x }; s`, 6},
+		{`s = ""; for (c in "doge") { s = c + s }; s`, "egod"},
+		{`s = ""; for (k in {"a": 1, "b": 2}) { s += k }; s`, "ab"},
+		{`s = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break }; s += x }; s`, 3},
+		{`f = func() { for (x in [1, 2, 3]) { if (x == 2) { return x } } }; f()`, 2},
+		{`for (x in 5) { x }`, "ERROR: object is not iterable: INTEGER"},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestInExpression(t *testing.T) {
+	tests := []expectedTest{
+		{`2 in [1, 2, 3]`, true},
+		{`4 in [1, 2, 3]`, false},
+		{`2.0 in [1, 2, 3]`, true},
+		{`[1] in [[1], [2]]`, true},
+		{`"a" in {"a": 1}`, true},
+		{`"b" in {"a": 1}`, false},
+		{`h = {"a": if (false) { 1 }}; "a" in h`, true},
+		{`"og" in "doge"`, true},
+		{`"cat" in "doge"`, false},
+		{`4 not in [1, 2, 3]`, true},
+		{`"a" not in {"a": 1}`, false},
+		{`1 + 1 in [2]`, true},
+		{`1 in "doge"`, "ERROR: left operand of `in` must be STRING, got INTEGER"},
+		{`1 in 1`, "ERROR: operator `in` not supported: INTEGER"},
+		{`"b" in process.lines("printf", ["a\nb\n"])`, true},
+		{`"x" in fs.lines("/")`, "ERROR: `fs.lines` failed: read /: is a directory"},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestDestructuringAssignment(t *testing.T) {
+	tests := []expectedTest{
+		{`a = 1; b = 2; a, b = b, a; [a, b]`, "[2, 1]"},
+		{`point = [3, 4]; [x, y] = point; x * y`, 12},
+		{`person = {"name": "doge", "age": 7}; {name, age} = person; name + string(age)`, "doge7"},
+		{`{"name": n} = {"name": "shibe"}; n`, "shibe"},
+		{`[a, [b, c]] = [1, [2, 3]]; a + b + c`, 6},
+		{`{"pos": [x, _]} = {"pos": [5, 6]}; x`, 5},
+		{`f = func() { return 1, 2 }; q, r = f(); q + r`, 3},
+		{`a, b = [1, 2]; a + b`, 3},
+		{"point = [1, 2]\n[x, y] = point\n[x, y]", "[1, 2]"},
+		{"arr = [[1, 2]]\nx = arr\n[3][0]", 3},
+		{"arr = [[1, 2]]\narr[0]\n  [1]", "[1]"},
+		{"arr = [[1, 2]]\narr[0][1]", 2},
+		{`[a, b] = [1, 2, 3]`, "ERROR: cannot destructure array of length 3 into 2 targets"},
+		{`[a, b] = 5`, "ERROR: cannot destructure INTEGER into array pattern [a, b]"},
+		{`{name} = {"age": 1}`, "ERROR: cannot destructure hash: missing key name"},
+		{`[a, 1] = [1, 1]`, "ERROR: invalid assignment target: 1"},
+		{`[a] += [1]`, "ERROR: cannot use += with destructuring assignment"},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestMatchExpression(t *testing.T) {
+	expr := `
+expr = {"type": "add", "lhs": 4, "rhs": {"type": "neg", "value": 1}};
+match (expr) {
+	case int(n) => n,
+	case {"type": "add", lhs, "rhs": {"type": "neg", value}} => lhs - value,
+	case {"type": "add", lhs, rhs} => lhs + rhs,
+	case _ => 0
+}`
+
+	tests := []expectedTest{
+		{`match (1) { case 1 => "one", case 2 => "two" }`, "one"},
+		{`match (2) { case 1 => "one", case 2 => "two" }`, "two"},
+		{`match (-3) { case -3 => "minus three" }`, "minus three"},
+		{`match ("doge") { case "cat" => 1, case "doge" => 2 }`, 2},
+		{`match ([1, 2]) { case [x] => x, case [x, y] => x + y }`, 3},
+		{`match ([1, [2, 3]]) { case [a, [_, c]] => a + c }`, 4},
+		{`match ({"type": "add", "lhs": 1, "rhs": 2}) { case {"type": "add", lhs, rhs} => lhs + rhs }`, 3},
+		{`match (5) { case x if x > 10 => "big", case x => "small" }`, "small"},
+		{`match (15) { case x if x > 10 => "big", case x => "small" }`, "big"},
+		{`match (1.5) { case int(_) => "int", case float(_) => "float" }`, "float"},
+		{`match ("a") { case string() => { y = 1; y + 1 } }`, 2},
+		{`x = 1; match (5) { case x => x }; x`, 1},
+		{`match (3) { case 1 => 1, case 2 => 2 }`, "ERROR: non-exhaustive match: no case matched 3"},
+		{`match (3) { case 1 + 2 => 1 }`, "ERROR: invalid pattern: (1 + 2)"},
+		{`match (3) { case foo(x) => 1 }`, "ERROR: unknown type in pattern: foo"},
+		{expr, 3},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestMatchUnreachableCase(t *testing.T) {
+	l := lexer.New(`match (1) { case x => 1, case 2 => 2 }`)
+	p := parser.New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 || errors[0] != "unreachable case after catch-all pattern" {
+		t.Errorf("expected unreachable case error. got=%v", errors)
+	}
+}
+
+func TestConditionalExpression(t *testing.T) {
+	tests := []expectedTest{
+		{`true ? 1 : 2`, 1},
+		{`false ? 1 : 2`, 2},
+		{`1 > 2 ? "a" : "b"`, "b"},
+		{`x = 5; x > 3 ? x * 2 : x`, 10},
+		{`x = 0 ? 1 : 2; x`, 1},
+		{`false ? 1 : true ? 2 : 3`, 2},
+		{`false ? 1 : false ? 2 : 3`, 3},
+		{`(true ? [1, 2] : [3])[1]`, 2},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestBlockValues(t *testing.T) {
+	tests := []expectedTest{
+		{`x = if (true) { 1 } else { 2 }; x`, 1},
+		{`x = if (false) { 1 } else { 2 }; x`, 2},
+		{`x = if (true) { y = 3 }; x`, 3},
+		{`x = if (false) { 1 }; x`, nil},
+		{`x = if (true) { }; x`, nil},
+		{`y = 0; x = while (y < 3) { y += 1 }; x`, nil},
+		{`x = for (i = 0; i < 3; i += 1) { i }; x`, nil},
+		{`x = for (i in [1, 2]) { i }; x`, nil},
+		{`a = b = 4; a + b`, 8},
+		{`x = 1; x += 2`, 3},
+		{`[a, b] = [1, 2]`, "[1, 2]"},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []expectedTest{
+		{`double = x => x * 2; double(4)`, 8},
+		{`add = (a, b) => a + b; add(2, 3)`, 5},
+		{`f = () => 7; f()`, 7},
+		{`f = (x) => x - 1; f(1)`, 0},
+		{`f = (a, b) => { c = a * b; c + 1 }; f(2, 3)`, 7},
+		{`(x => x + 1)(1)`, 2},
+		{`string(map([1, 2, 3], x => x * 2))`, "[2, 4, 6]"},
+		{`string(filter([1, 2, 3, 4], x => x % 2 == 0))`, "[2, 4]"},
+		{`abs = x => x < 0 ? -x : x; abs(-3)`, 3},
+		{`match (1) { case x => x + 1 }`, 2},
+		{`ok = true; match (1) { case x if ok => x }`, 1},
+		{`match ([1, 5]) { case x if any(x, y => y > 4) => 1, case _ => 0 }`, 1},
+		{`match ([1, 2]) { case x if any(x, y => y > 4) => 1, case _ => 0 }`, 0},
+		{`match ([1, 2]) { case [a, b] if len(filter([a, b], n => n > 1)) == 1 => b }`, 2},
+		{`match (2) { case x if (y => y == 2)(x) => 1, case _ => 0 }`, 1},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestArrowFunctionErrors(t *testing.T) {
+	l := lexer.New(`(a, 1) => a`)
+	p := parser.New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 || errors[0] != "invalid arrow function parameter: 1" {
+		t.Errorf("expected invalid parameter error. got=%v", errors)
+	}
+}
+
+func TestPipeExpression(t *testing.T) {
+	tests := []expectedTest{
+		{`[1, 2, 3] |> sum`, 6},
+		{`[1, 2, 3] |> map(x => x * 2) |> sum`, 12},
+		{`[1, 2, 3, 4] |> map(x => x * 2) |> filter(x => x > 4) |> sum`, 14},
+		{`"doge" |> len`, 4},
+		{`inc = x => x + 1; 1 |> inc |> inc`, 3},
+		{`[1, 2] |> sum == 3`, true},
+		{`total = [1, 2] |> sum; total`, 3},
+		{`1 |> 2`, "ERROR: not a function: INTEGER"},
+	}
+
+	ExpectedTests(t, tests)
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []inspectTest{
+		{`x = 5; f"x is {x}"`, "x is 5"},
+		{`a = 1; b = 2; f"total {a + b}!"`, "total 3!"},
+		{`f"no expressions"`, "no expressions"},
+		{`f""`, ""},
+		{`f"{{literal}} {1}"`, "{literal} 1"},
+		{`h = {"a": 1}; f"a={h["a"]}"`, "a=1"},
+		{`x = 2; f"{x > 1 ? "big" : "small"}"`, "big"},
+		{`f"{[1, 2]} {"s"} {true}"`, "[1, 2] s true"},
+		{`pi = 3.14159; f"{pi:.2f}"`, "3.14"},
+		{`f"[{42:5d}]"`, "[   42]"},
+		{`f"[{42:-5d}]"`, "[42   ]"},
+		{`f"[{"doge":^8}]"`, "[  doge  ]"},
+		{`f"[{3.5:08.3f}]"`, "[0003.500]"},
+		{`f"{255:x} {255:#X} {5:b} {8:o}"`, "ff 0XFF 101 10"},
+		{`f"{1234.5:e}"`, "1.234500e+03"},
+		{`f"{"doge":.2}"`, "do"},
+		{`f"{65:c}"`, "A"},
+		{`f"{"a":d}"`, "ERROR: format verb %d needs INTEGER, got STRING"},
+		{`f"{1:q}"`, `ERROR: invalid format spec "q"`},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestInterpolatedStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`f"{x"`, "unterminated expression in f-string"},
+		{`f"x}"`, "single '}' is not allowed in f-string"},
+		{`f"{}"`, "empty expression in f-string"},
+		{`f"{1 2}"`, `unexpected INT in f-string expression "1 2"`},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestFormatBuiltin(t *testing.T) {
+	tests := []inspectTest{
+		{`format("%d apples", 3)`, "3 apples"},
+		{`format("%5d|%-5d|%05d", 42, 42, 42)`, "   42|42   |00042"},
+		{`format("%.2f %8.3f", 3.14159, 2.5)`, "3.14    2.500"},
+		{`format("%+d %+.1f", 5, 1.25)`, "+5 +1.2"},
+		{`format("%x %X %#x %b %o", 255, 255, 255, 5, 8)`, "ff FF 0xff 101 10"},
+		{`format("%e", 1234.5)`, "1.234500e+03"},
+		{`format("%v %v %v", [1, 2], "s", 1.5)`, "[1, 2] s 1.5"},
+		{`format("%-6s|%6s|%^6s|", "ab", "ab", "ab")`, "ab    |    ab|  ab  |"},
+		{`format("100%%")`, "100%"},
+		{`format("%d")`, `ERROR: missing argument for format directive "%d"`},
+		{`format("%d", 1, 2)`, "ERROR: too many arguments for format. got=2, want=1"},
+		{`format("%5", 1)`, `ERROR: incomplete format directive "%5"`},
+		{`format("%d", "a")`, "ERROR: format verb %d needs INTEGER, got STRING"},
+		{`format("%^2000000s", "a")`, "ERROR: format width and precision must be at most 1000000"},
+		{`format("%.2000000f", 1.5)`, "ERROR: format width and precision must be at most 1000000"},
+		{`format(1)`, "ERROR: first argument to `format` must be STRING, got INTEGER"},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestPrintOptions(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedStdout string
+		expectedStderr string
+	}{
+		{`print(1, "a", [2])`, "1 a [2]\n", ""},
+		{`print_with({"sep": ", "}, 1, 2)`, "1, 2\n", ""},
+		{`print_with({"end": ""}, 1); print_with({"end": "!"}, 2)`, "12!", ""},
+		{`print_with({"stream": "stderr"}, "oops")`, "", "oops\n"},
+		{`print_with({})`, "\n", ""},
+		{`print({"a": 1})`, "{a: 1}\n", ""},
+		{`print("config:", {"end": "!"})`, "config: {end: !}\n", ""},
+		{`print({"sep": 1})`, "{sep: 1}\n", ""},
+		{`print_with({"sep": "-"}, {"sep": 1}, {"end": "!"})`, "{sep: 1}-{end: !}\n", ""},
+	}
+
+	for _, tt := range tests {
+		evaluated, stdout, stderr := EvalTestWithOutput(tt.input)
+		if IsError(evaluated) {
+			t.Errorf("unexpected error for %q: %s", tt.input, evaluated.Inspect())
+		}
+
+		if stdout != tt.expectedStdout || stderr != tt.expectedStderr {
+			t.Errorf("wrong output for %q. expected=%q/%q, got=%q/%q",
+				tt.input, tt.expectedStdout, tt.expectedStderr, stdout, stderr)
+		}
+	}
+}
+
+func TestPrintWithErrors(t *testing.T) {
+	tests := []inspectTest{
+		{`print_with(", ", 1)`, "ERROR: first argument to `print_with` must be HASH, got STRING"},
+		{`print_with({"color": "red"}, 1)`, "ERROR: unknown option for `print_with`: color"},
+		{`print_with({"sep": 1}, 1)`, "ERROR: print option `sep` must be STRING, got INTEGER"},
+		{`print_with({"stream": "file"}, 1)`, `ERROR: print stream must be "stdout" or "stderr", got "file"`},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []inspectTest{
+		{`split("a,b,,c", ",")`, "[a, b, , c]"},
+		{`split("  a b	c ")`, "[a, b, c]"},
+		{`split("abc", "")`, "ERROR: separator for `split` must not be empty"},
+		{`join(["a", "b", "c"], "-")`, "a-b-c"},
+		{`join(["a", "b"])`, "ab"},
+		{`join(["a", 1], "-")`, "ERROR: element 1 of array passed to `join` must be STRING, got INTEGER"},
+		{`join("ab", "-")`, "ERROR: first argument to `join` must be ARRAY, got STRING"},
+		{`strip("  hi 	")`, "hi"},
+		{`strip("xxhixx", "x")`, "hi"},
+		{`lstrip("  hi  ") + "|"`, "hi  |"},
+		{`rstrip("  hi  ") + "|"`, "  hi|"},
+		{`rstrip("hi!?!", "!?")`, "hi"},
+		{`trim_prefix("foobar", "foo")`, "bar"},
+		{`trim_suffix("foobar", "bar")`, "foo"},
+		{`replace("aaa", "a", "b")`, "bbb"},
+		{`replace("aaa", "a", "b", 2)`, "bba"},
+		{`upper("Hello")`, "HELLO"},
+		{`lower("Hello")`, "hello"},
+		{`startswith("hello", "he")`, "true"},
+		{`endswith("hello", "he")`, "false"},
+		{`find("hello", "l")`, "2"},
+		{`find("hello", "z")`, "-1"},
+		{`index("hello", "lo")`, "3"},
+		{`index("hello", "z")`, `ERROR: substring not found: "z"`},
+		{`count("banana", "an")`, "2"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", -1)`, "ERROR: negative repeat count: -1"},
+		{`repeat("ab", 4611686018427387904)`, "ERROR: result of `repeat` would be longer than 1073741824 bytes"},
+		{`repeat("", 4611686018427387904)`, ""},
+		{`pad("ab", 5) + "|"`, "ab   |"},
+		{`pad("ab", 5, "*", "right")`, "***ab"},
+		{`pad("ab", 6, "*", "center")`, "**ab**"},
+		{`pad("abcdef", 3)`, "abcdef"},
+		{`pad("ab", -9223372036854775807 - 1)`, "ab"},
+		{`pad("ab", 9223372036854775807, "*", "center")`, "ERROR: result of `pad` would be longer than 1073741824 bytes"},
+		{`pad("ab", 5, "**")`, `ERROR: fill for ` + "`pad`" + ` must be a single character, got "**"`},
+		{`pad("ab", 5, " ", "up")`, `ERROR: alignment for ` + "`pad`" + ` must be "left", "right" or "center", got "up"`},
+		{`chars("häh")`, "[h, ä, h]"},
+		{`ord("A")`, "65"},
+		{`ord("€")`, "8364"},
+		{`ord("ab")`, `ERROR: argument to ` + "`ord`" + ` must be a single character, got "ab"`},
+		{`chr(97)`, "a"},
+		{`chr(-1)`, "ERROR: invalid code point for `chr`: -1"},
+		{`reverse("häh!")`, "!häh"},
+		{`upper(1)`, "ERROR: first argument to `upper` must be STRING, got INTEGER"},
+		{`startswith("a", 1)`, "ERROR: second argument to `startswith` must be STRING, got INTEGER"},
+		{`replace("a", "b")`, "ERROR: wrong number of arguments. got=2, want=3 to 4"},
+		{`lower()`, "ERROR: wrong number of arguments. got=0, want=1"},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestArrayBuiltins(t *testing.T) {
+	tests := []inspectTest{
+		{`sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`a = [3, 1, 2]; sort(a); a`, "[3, 1, 2]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{`sort([[2, 1], [1, 2], [1, 1]])`, "[[1, 1], [1, 2], [2, 1]]"},
+		{`sort(["bb", "a", "cc", "d"], len)`, "[a, d, bb, cc]"},
+		{`sort([3, -1, 2], x => -x)`, "[3, 2, -1]"},
+		{`sort([1, "a"])`, "ERROR: cannot compare STRING and INTEGER"},
+		{`reverse([1, 2, 3])`, "[3, 2, 1]"},
+		{`reduce([1, 2, 3, 4], (a, b) => a + b)`, "10"},
+		{`reduce([1, 2, 3], (a, b) => a * b, 10)`, "60"},
+		{`fold([], (a, b) => a + b, 0)`, "0"},
+		{`reduce([], (a, b) => a + b)`, "ERROR: `reduce` of empty array with no initial value"},
+		{`reduce([1, 2], (a, b, c) => a)`, "ERROR: wrong number of arguments. got=2, want=3"},
+		{`reduce([1, 2], 3)`, "ERROR: second argument to `reduce` must be FUNCTION, got INTEGER"},
+		{`zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`zip([1], [2], [3])`, "[[1, 2, 3]]"},
+		{`zip([1], 2)`, "ERROR: arguments to `zip` must be ARRAY, got INTEGER"},
+		{`enumerate(["a", "b"])`, "[[0, a], [1, b]]"},
+		{`enumerate(["a", "b"], 1)`, "[[1, a], [2, b]]"},
+		{`any([false, 1])`, "true"},
+		{`any([])`, "false"},
+		{`any([1, 2, 3], x => x > 2)`, "true"},
+		{`all([1, 2, 3], x => x > 2)`, "false"},
+		{`all([])`, "true"},
+		{`all([1, 2], string)`, "true"},
+		{`index_of([1, 2, 3], 2)`, "1"},
+		{`index_of([[1], [2]], [2])`, "1"},
+		{`index_of([1, 2], 5)`, "-1"},
+		{`contains([1, 2.0], 2)`, "true"},
+		{`contains([1, 2], 3)`, "false"},
+		{`a = [1, 3]; insert(a, 1, 2); a`, "[1, 2, 3]"},
+		{`a = [1]; insert(a, 1, 2); insert(a, -2, 0); a`, "[0, 1, 2]"},
+		{`insert([1], 3, 2)`, "ERROR: Index out of bounds!"},
+		{`insert(freeze([1]), 0, 2)`, "ERROR: cannot modify frozen array"},
+		{`a = [1, 2, 3]; [pop(a), a]`, "[3, [1, 2]]"},
+		{`a = [1, 2, 3]; [pop(a, 0), a]`, "[1, [2, 3]]"},
+		{`pop([])`, "ERROR: pop from empty array"},
+		{`pop([1], 1)`, "ERROR: Index out of bounds!"},
+		{`concat([1], [], [2, 3])`, "[1, 2, 3]"},
+		{`concat()`, "[]"},
+		{`flatten([1, [2, [3]], 4])`, "[1, 2, [3], 4]"},
+		{`flatten([1, [2, [3, [4]]]], 10)`, "[1, 2, 3, 4]"},
+		{`unique([1, 2, 1, 3, 2.0])`, "[1, 2, 3]"},
+		{`unique([{"a": 1}, {"a": 1}, [1], [1]])`, "[{a: 1}, [1]]"},
+		{`chunk([1, 2, 3, 4, 5], 2)`, "[[1, 2], [3, 4], [5]]"},
+		{`chunk([1], 0)`, "ERROR: chunk size must be positive, got 0"},
+		{`a = [[1]]; b = copy(a); append(b, 2); append(b[0], 3); a`, "[[1, 3]]"},
+		{`a = [[1]]; b = deepcopy(a); append(b[0], 3); [a, b]`, "[[[1]], [[1, 3]]]"},
+		{`h = {"a": [1]}; c = deepcopy(h); append(c["a"], 2); [h, c]`, "[{a: [1]}, {a: [1, 2]}]"},
+		{`a = [1]; append(a, a); b = deepcopy(a); b[1][1][0]`, "1"},
+		{`a = copy(freeze([1])); append(a, 2); a`, "[1, 2]"},
+		{`copy(1)`, "ERROR: argument to `copy` must be ARRAY, HASH or SET, got INTEGER"},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []inspectTest{
+		{`keys({"b": 1, "a": 2, 3: 3})`, "[b, a, 3]"},
+		{`values({"b": 1, "a": 2})`, "[1, 2]"},
+		{`items({"b": 1, "a": 2})`, "[[b, 1], [a, 2]]"},
+		{`keys({})`, "[]"},
+		{`keys([1])`, "ERROR: first argument to `keys` must be HASH, got ARRAY"},
+		{`has({"a": 1}, "a")`, "true"},
+		{`has({1: 1}, 1.0)`, "true"},
+		{`has({"a": 1}, "b")`, "false"},
+		{`has({"a": 1}, {})`, "ERROR: unusable as hash key: HASH"},
+		{`get({"a": 1}, "a")`, "1"},
+		{`get({"a": 1}, "b", 0)`, "0"},
+		{`get({"a": 1}, "b")`, "null"},
+		{`h = {"a": 1, "b": 2, "c": 3}; [delete(h, "b"), h]`, "[2, {a: 1, c: 3}]"},
+		{`h = {"a": 1}; delete(h, "z"); h`, "{a: 1}"},
+		{`h = {"a": 1, "b": 2}; delete(h, "a"); update(h, {"a": 3}); keys(h)`, "[b, a]"},
+		{`merge({"a": 1, "b": 2}, {"b": 3, "c": 4})`, "{a: 1, b: 3, c: 4}"},
+		{`h = {"a": 1}; merge(h, {"b": 2}); h`, "{a: 1}"},
+		{`merge({}, 1)`, "ERROR: arguments to `merge` must be HASH, got INTEGER"},
+		{`h = {"a": 1}; update(h, {"a": 2}, {"b": 3}); h`, "{a: 2, b: 3}"},
+		{`from_pairs([["a", 1], [[1, 2], 2]])`, "{a: 1, [1, 2]: 2}"},
+		{`from_pairs(items({"x": 1, "y": 2}))`, "{x: 1, y: 2}"},
+		{`from_pairs([["a", 1], [2]])`, "ERROR: element 1 of array passed to `from_pairs` must be a [key, value] pair, got [2]"},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestMemberExpression(t *testing.T) {
+	tests := []inspectTest{
+		{`math`, "<module math>"},
+		{`h = {"a": {"b": 2}}; h.a.b`, "2"},
+		{`h = {"match": 1}; h.match`, "1"},
+		{`{"a": 1}.z`, "null"},
+		{`[{"a": [1, 2]}][0].a[1]`, "2"},
+		{`math.nope`, "ERROR: module math has no member nope"},
+		{`[1].a`, "ERROR: cannot access member a of ARRAY"},
+		{`math = 1; math`, "1"},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestMathModule(t *testing.T) {
+	tests := []inspectTest{
+		{`math.sqrt(16)`, "4"},
+		{`math.pi > 3.14 && math.pi < 3.15`, "true"},
+		{`math.log(8, 2)`, "3"},
+		{`math.log(math.e)`, "1"},
+		{`math.atan(1, 1) == math.pi / 4`, "true"},
+		{`math.hypot(3, 4)`, "5"},
+		{`math.is_nan(math.nan)`, "true"},
+		{`math.is_inf(-math.inf)`, "true"},
+		{`math.sqrt("a")`, "ERROR: first argument to `math.sqrt` must be INTEGER or FLOAT, got STRING"},
+		{`math.abs(-3)`, "3"},
+		{`math.abs(-2.5)`, "2.5"},
+		{`math.abs(math.min_int)`, "ERROR: integer overflow in `math.abs`"},
+		{`math.floor(-2.5)`, "-3"},
+		{`math.ceil(2.1)`, "3"},
+		{`math.round(2.5)`, "3"},
+		{`math.round(-2.5)`, "-3"},
+		{`math.round(3.14159, 2)`, "3.14"},
+		{`math.floor(7)`, "7"},
+		{`math.floor(math.inf)`, "ERROR: cannot convert +Inf to INTEGER in `math.floor`"},
+		{`math.gcd(12, 18)`, "6"},
+		{`math.gcd(-4, 6, 10)`, "2"},
+		{`math.lcm(4, 6, 10)`, "60"},
+		{`math.lcm(3, 0)`, "0"},
+		{`math.gcd(1, 2.0)`, "ERROR: arguments to `math.gcd` must be INTEGER, got FLOAT"},
+		{`math.isqrt(99)`, "9"},
+		{`math.isqrt(-1)`, "ERROR: argument to `math.isqrt` must not be negative, got -1"},
+		{`math.powmod(2, 100, 1000000007)`, "976371285"},
+		{`math.powmod(-2, 3, 5)`, "2"},
+		{`math.powmod(2, 3, 0)`, "ERROR: modulus for `math.powmod` must be positive, got 0"},
+		{`map([1, 2, 3, 4, 97, 561], math.is_prime)`, "[false, true, true, false, true, false]"},
+		{`math.factorize(600851475143)`, "[71, 839, 1471, 6857]"},
+		{`math.factorize(12)`, "[2, 2, 3]"},
+		{`math.factorize(1)`, "[]"},
+		{`math.factorize(0)`, "ERROR: argument to `math.factorize` must be positive, got 0"},
+		{`math.divmod(7, 2)`, "[3, 1]"},
+		{`math.divmod(-7, 2)`, "[-4, 1]"},
+		{`math.divmod(7, -2)`, "[-4, -1]"},
+		{`math.divmod(1, 0)`, "ERROR: division by zero in `math.divmod`"},
+		{`math.factorial(0)`, "1"},
+		{`math.factorial(20)`, "2432902008176640000"},
+		{`math.factorial(21)`, "ERROR: integer overflow in `math.factorial`"},
+		{`math.comb(5, 2)`, "10"},
+		{`math.comb(2, 5)`, "0"},
+		{`math.perm(5, 2)`, "20"},
+		{`math.comb(200, 100)`, "ERROR: integer overflow in `math.comb`"},
+		{`math.comb(5, 0)`, "1"},
+		{`math.comb(5, 5)`, "1"},
+		{`math.comb(66, 33)`, "7219428434016265740"},
+		{`math.comb(68, 34)`, "ERROR: integer overflow in `math.comb`"},
+		{`math.comb(1000000000000, 999999999999)`, "1000000000000"},
+		{`math.comb(9223372036854775807, 2)`, "ERROR: integer overflow in `math.comb`"},
+		{`math.comb(9223372036854775807, 4611686018427387903)`, "ERROR: integer overflow in `math.comb`"},
+		{`math.perm(5, 0)`, "1"},
+		{`math.perm(20, 20)`, "2432902008176640000"},
+		{`math.perm(21, 21)`, "ERROR: integer overflow in `math.perm`"},
+		{`math.perm(9223372036854775807, 9223372036854775807)`, "ERROR: integer overflow in `math.perm`"},
+		{`3 ** 39`, "4052555153018976267"},
+		{`2 ** 0`, "1"},
+		{`2 ** 62`, "4611686018427387904"},
+		{`(-2) ** 63`, "-9223372036854775808"},
+		{`(-1) ** 9223372036854775807`, "-1"},
+		{`2 ** 63`, "ERROR: integer overflow in `**`"},
+		{`2 ** 64`, "ERROR: integer overflow in `**`"},
+		{`3 ** 41`, "ERROR: integer overflow in `**`"},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestLoopAssignment(t *testing.T) {
+	tests := []inspectTest{
+		{`s = 0; for (i = 1; i <= 3; i += 1) { s += i }; s`, "6"},
+		{`s = 0; for (i = 0; i < 3; i += 1) { for (j = 0; j < 3; j += 1) { s += 1 } }; s`, "9"},
+		{`s = 0; for (x in [1, 2]) { for (y in [1, 2]) { if (x == y) { s += x } } }; s`, "3"},
+		{`for (i = 0; i < 3; i += 1) { }; i`, "ERROR: identifier not found: i"},
+		{`for (i = 0; i < 3; i += 1) { t = i }; t`, "ERROR: identifier not found: t"},
+		{`i = 10; for (i = 0; i < 3; i += 1) { }; i`, "10"},
+		{`i = 10; s = 0; for (i = 0; i < 3; i += 1) { s += i }; [i, s]`, "[10, 3]"},
+		{`i = 0; for (i += 1; i < 3; i += 1) { }; i`, "3"},
+		{`x = 5; for (x in [1, 2]) { }; x`, "5"},
+		{`x = 5; s = 0; for (x in [1, 2]) { s += x }; [x, s]`, "[5, 3]"},
+		{`for (x in [1, 2]) { }; x`, "ERROR: identifier not found: x"},
+		{`last = 0; for (x in [1, 2]) { last = x }; last`, "2"},
+		{`n = 0; i = 0; while (i < 3) { i += 1; n += i }; [i, n]`, "[3, 6]"},
+		{`f = () => { s = 0; for (i = 1; i <= 4; i += 1) { s += i }; s }; f()`, "10"},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestRandomModule(t *testing.T) {
+	draws := `random.seed(42); [random.int(1, 100), random.float(), random.choice([1, 2, 3]), random.sample([1, 2, 3, 4], 2), random.gauss(10, 2)]`
+
+	first := EvalTest(draws)
+	if IsError(first) {
+		t.Fatalf("unexpected error: %s", first.Inspect())
+	}
+	if second := EvalTest(draws); second.Inspect() != first.Inspect() {
+		t.Errorf("seeded draws differ. first=%s, second=%s", first.Inspect(), second.Inspect())
+	}
+
+	// seeding one interpreter must not change the numbers of another
+	seeded := object.NewEnvironment()
+	other := object.NewEnvironment()
+	for _, env := range []*object.Environment{seeded, other} {
+		Eval(parser.New(lexer.New(`random.seed(7)`)).ParseProgram(), env)
+	}
+	Eval(parser.New(lexer.New(`random.seed(8); random.float()`)).ParseProgram(), seeded)
+	expected := EvalTest(`random.seed(7); random.float()`)
+	if got := Eval(parser.New(lexer.New(`random.float()`)).ParseProgram(), other); got.Inspect() != expected.Inspect() {
+		t.Errorf("interpreters share random state. expected=%s, got=%s", expected.Inspect(), got.Inspect())
+	}
+
+	tests := []inspectTest{
+		{`s = set([]); for (i = 0; i < 200; i += 1) { s = s | set([random.int(-2, 2)]) }; s == set([-2, -1, 0, 1, 2])`, "true"},
+		{`random.int(3, 3)`, "3"},
+		{`x = random.float(); x >= 0 && x < 1`, "true"},
+		{`a = [1, 2, 3, 4, 5]; random.shuffle(a); sort(a)`, "[1, 2, 3, 4, 5]"},
+		{`sort(random.sample([1, 2, 3], 3))`, "[1, 2, 3]"},
+		{`random.int(2, 1)`, "ERROR: empty range for `random.int`: 2 > 1"},
+		{`random.choice([])`, "ERROR: cannot choose from an empty array"},
+		{`random.shuffle(freeze([1]))`, "ERROR: cannot modify frozen array"},
+		{`random.sample([1], 2)`, "ERROR: sample size for `random.sample` must be between 0 and 1, got 2"},
+		{`random.seed("a")`, "ERROR: first argument to `random.seed` must be INTEGER, got STRING"},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestFsModule(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fs.write_file("{dir}/a.txt", "one
+two
+")`, "null"},
+		{`fs.append_file("{dir}/a.txt", "three")`, "null"},
+		{`fs.read_file("{dir}/a.txt")`, "one\ntwo\nthree"},
+		{`fs.read_lines("{dir}/a.txt")`, "[one, two, three]"},
+		{`s = []; for (line in fs.lines("{dir}/a.txt")) { append(s, upper(line)) }; s`, "[ONE, TWO, THREE]"},
+		{`for (line in fs.lines("{dir}/a.txt")) { break }; "two" in fs.lines("{dir}/a.txt")`, "true"},
+		{`fs.exists("{dir}/a.txt")`, "true"},
+		{`fs.exists("{dir}/nope.txt")`, "false"},
+		{`fs.mkdir("{dir}/sub/deeper"); fs.write_file("{dir}/sub/b.txt", "")`, "null"},
+		{`fs.listdir("{dir}")`, "[a.txt, sub]"},
+		{`fs.glob("{dir}/*.txt") == ["{dir}/a.txt"]`, "true"},
+		{`s = fs.stat("{dir}/a.txt"); [s.name, s.size, s.is_dir]`, "[a.txt, 13, false]"},
+		{`fs.stat("{dir}/sub").is_dir`, "true"},
+		{`fs.rename("{dir}/sub/b.txt", "{dir}/c.txt"); fs.listdir("{dir}/sub")`, "[deeper]"},
+		{`fs.remove("{dir}/c.txt"); fs.exists("{dir}/c.txt")`, "false"},
+		{`fs.read_file("{dir}/nope.txt")`, "ERROR: `fs.read_file` failed: open {dir}/nope.txt: no such file or directory"},
+		{`content, err = try(fs.read_file, "{dir}/nope.txt"); [content, err]`, "[null, `fs.read_file` failed: open {dir}/nope.txt: no such file or directory]"},
+		{`try(fs.read_lines, "{dir}/a.txt")`, "[[one, two, three], null]"},
+		{`for (line in fs.lines("{dir}/nope.txt")) { }`, "ERROR: `fs.lines` failed: open {dir}/nope.txt: no such file or directory"},
+		{`fs.write_file("{dir}/a.txt", 1)`, "ERROR: second argument to `fs.write_file` must be STRING, got INTEGER"},
+		{`try(1)`, "ERROR: first argument to `try` must be FUNCTION, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		input := strings.Replace(tt.input, "{dir}", dir, -1)
+		expected := strings.Replace(tt.expected, "{dir}", dir, -1)
+
+		InspectObjectTest(t, input, EvalTest(input), expected)
+	}
+}
+
+func TestStdin(t *testing.T) {
+	tests := []struct {
+		input          string
+		stdin          string
+		expected       string
+		expectedStdout string
+	}{
+		{`input("name? ")`, "doge\nrest\n", "doge", "name? "},
+		{`[input(), input(), input()]`, "a\r\nb", "[a, b, null]", ""},
+		{`read_stdin()`, "a\nb\n", "a\nb\n", ""},
+		{`first = input(); [first, read_stdin()]`, "a\nb\nc", "[a, b\nc]", ""},
+		{`s = []; for (line in stdin_lines()) { append(s, line) }; s`, "x\ny\n", "[x, y]", ""},
+		{`for (line in stdin_lines()) { break }; input()`, "x\ny\n", "y", ""},
+		{`input(1)`, "", "ERROR: first argument to `input` must be STRING, got INTEGER", ""},
+	}
+
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		env := object.NewEnvironment()
+		env.Context().Stdin = bufio.NewReader(strings.NewReader(tt.stdin))
+		env.Context().Stdout = &stdout
+		InitBuiltins()
+
+		InspectObjectTest(t, tt.input, Eval(program, env), tt.expected)
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("wrong stdout for %q. expected=%q, got=%q", tt.input, tt.expectedStdout, stdout.String())
+		}
+	}
+}
+
+func TestOsModule(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Context().Args = []string{"script.doge", "-v", "input.txt"}
+	InitBuiltins()
+
+	argv := Eval(parser.New(lexer.New(`os.argv()`)).ParseProgram(), env)
+	if argv.Inspect() != "[script.doge, -v, input.txt]" {
+		t.Errorf("wrong argv. got=%s", argv.Inspect())
+	}
+
+	tests := []inspectTest{
+		{`os.setenv("DOGE_TEST_VAR", "wow"); os.getenv("DOGE_TEST_VAR")`, "wow"},
+		{`os.environ()["DOGE_TEST_VAR"]`, "wow"},
+		{`os.getenv("DOGE_TEST_UNSET")`, "null"},
+		{`os.getenv("DOGE_TEST_UNSET", "default")`, "default"},
+		{`os.setenv("DOGE_TEST_VAR", 1)`, "ERROR: second argument to `os.setenv` must be STRING, got INTEGER"},
+		{`os.argv()`, "[]"},
+	}
+
+	InspectTests(t, tests)
+	os.Unsetenv("DOGE_TEST_VAR")
+
+	exits := []struct {
+		input        string
+		expectedCode int
+	}{
+		{`os.exit(); 1`, 0},
+		{`os.exit(3); 1`, 3},
+		{`f = func() { for (x in [1, 2]) { os.exit(x + 4) } }; f(); 1`, 5},
+		{`try(os.exit, 2); 1`, 2},
+	}
+
+	for _, tt := range exits {
+		evaluated := EvalTest(tt.input)
+		err, ok := evaluated.(*object.Error)
+		if !ok || !err.Exit || err.Code != tt.expectedCode {
+			t.Errorf("wrong result for %q. expected exit with code %d, got=%+v", tt.input, tt.expectedCode, evaluated)
+		}
+	}
+}
+
+func TestJsonModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		text     string
+		expected string
+	}{
+		{`json.parse(text)`, `{"b": [1, 2.5, true, null], "a": {"x": "y"}}`, "{b: [1, 2.5, true, null], a: {x: y}}"},
+		{`json.parse(text).b[1] + 1`, `{"b": [1, 2.5]}`, "3.5"},
+		{`json.parse(text)`, `"a\nb ä"`, "a\nb ä"},
+		{`json.parse(text)`, `123456789012`, "123456789012"},
+		{`json.parse(text)`, `1e3`, "1000"},
+		{`json.parse(text)`, `{"a": 1,
+  "b": }`, "ERROR: invalid JSON at line 2, column 8: "},
+		{`json.parse(text)`, `[1, x]`, "ERROR: invalid JSON at line 1, column 5: "},
+		{`json.parse(text)`, `[1, 2`, "ERROR: invalid JSON at line 1, column 6: unexpected end of JSON input"},
+		{`json.parse(text)`, ``, "ERROR: invalid JSON at line 1, column 1: unexpected end of JSON input"},
+		{`json.parse(text)`, `{} {}`, "ERROR: invalid JSON at line 1, column 4: unexpected data after JSON value"},
+		{`json.stringify(json.parse(text))`, `{"b":[1,2.5,true,null],"a":{"x":"y"}}`, `{"b":[1,2.5,true,null],"a":{"x":"y"}}`},
+		{`json.stringify({"q": text, 1: 2.0, "e": [], "h": {}})`, `say "<hi>"`, `{"q":"say \"<hi>\"","1":2.0,"e":[],"h":{}}`},
+		{`json.stringify({"b": 1, "a": [1, {"c": 2}]}, {"indent": 2, "sort_keys": true})`, "", "{\n  \"a\": [\n    1,\n    {\n      \"c\": 2\n    }\n  ],\n  \"b\": 1\n}"},
+		{`json.stringify([1, [2]], {"indent": text})`, "\t", "[\n\t1,\n\t[\n\t\t2\n\t]\n]"},
+		{`x = [1]; json.stringify([x, x])`, "", "[[1],[1]]"},
+		{`a = [1]; append(a, a); json.stringify(a)`, "", "ERROR: cannot serialize cyclic structure to JSON at $[1]"},
+		{`h = {"a": {}}; update(h["a"], {"b": h}); json.stringify(h)`, "", "ERROR: cannot serialize cyclic structure to JSON at $.a.b"},
+		{`json.stringify({"f": [len]})`, "", "ERROR: cannot serialize BUILTIN to JSON at $.f[0]"},
+		{`json.stringify({[1]: 1})`, "", "ERROR: cannot use ARRAY as JSON object key at $"},
+		{`json.stringify(math.nan)`, "", "ERROR: cannot serialize NaN to JSON at $"},
+		{`json.stringify(1, {"indent": true})`, "", "ERROR: invalid value for `json.stringify` option indent: true"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		env := object.NewEnvironment()
+		env.Set("text", &object.String{Value: tt.text})
+		InitBuiltins()
+
+		// the wording of syntax errors comes from encoding/json, so only
+		// the position is checked for those
+		evaluated := Eval(program, env)
+		if evaluated == nil || !strings.HasPrefix(evaluated.Inspect(), tt.expected) ||
+			(!strings.HasSuffix(tt.expected, ": ") && evaluated.Inspect() != tt.expected) {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestReModule(t *testing.T) {
+	tests := []inspectTest{
+		{`re.match("\d+", "42 apples").match`, "42"},
+		{`re.match("\d+", "apples 42")`, "null"},
+		{`m = re.search("(\d+) (\w+)", "got 42 apples"); [m.match, m.start, m.end, m.groups]`, "[42 apples, 4, 13, [42, apples]]"},
+		{`re.search("(?P<y>\d{4})-(?P<m>\d{2})", "on 2024-05").named`, "{y: 2024, m: 05}"},
+		{`re.search("a(x)?b", "ab").groups`, "[null]"},
+		{`re.search("z", "abc")`, "null"},
+		{`re.find_all("\d+", "1, 22 and 333")`, "[1, 22, 333]"},
+		{`re.find_all("(\w)=(\d)", "a=1 b=2")`, "[[a, 1], [b, 2]]"},
+		{`re.find_all("x(\d)", "x1 x2")`, "[1, 2]"},
+		{`re.replace("(\w+)@(\w+)", "me@home you@work", "$2:$1")`, "home:me work:you"},
+		{`re.replace("(?P<n>\d+)", "a1 b22", "<${n}>", 1)`, "a<1> b22"},
+		{`re.replace("\d+", "3 apples, 12 pears", m => string(int(m.match) * 2))`, "6 apples, 24 pears"},
+		{`re.replace("\d", "a1", m => 1)`, "ERROR: replacement function for `re.replace` must return STRING, got INTEGER"},
+		{`re.split("\s*,\s*", "a , b,c")`, "[a, b, c]"},
+		{`re.split(",", "a,b,c", 2)`, "[a, b,c]"},
+		{`p = re.compile("[aeiou]"); [re.find_all(p, "doge"), re.split(p, "doge")]`, "[[o, e], [d, g, ]]"},
+		{`re.compile("(?P<word>\w+)")`, `re.compile("(?P<word>\\w+)")`},
+		{`re.compile("(")`, "ERROR: invalid regular expression in `re.compile`: error parsing regexp: missing closing ): `(`"},
+		{`re.match(1, "a")`, "ERROR: first argument to `re.match` must be STRING or PATTERN, got INTEGER"},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestTimeModule(t *testing.T) {
+	tests := []inspectTest{
+		{`time.date(2024, 2, 29, 13, 5, 9, "UTC")`, "2024-02-29T13:05:09Z"},
+		{`t = time.date(2024, 2, 29, 13, 5, 9, "UTC"); [t.year, t.month, t.day, t.hour, t.minute, t.second, t.weekday, t.yearday]`, "[2024, 2, 29, 13, 5, 9, Thursday, 60]"},
+		{`time.format(time.date(2024, 1, 2, "UTC"), "Jan 2, 2006 at 15:04")`, "Jan 2, 2024 at 00:00"},
+		{`time.format(time.date(2024, 1, 2, 3, 4, 5, "UTC"), time.date_time)`, "2024-01-02 03:04:05"},
+		{`time.in_zone(time.date(2024, 7, 1, 12, 0, 0, "UTC"), "Europe/Berlin")`, "2024-07-01T14:00:00+02:00"},
+		{`time.date(2024, 1, 1, "America/New_York").zone`, "EST"},
+		{`time.parse("2024-03-10 08:30", "2006-01-02 15:04")`, "2024-03-10T08:30:00Z"},
+		{`time.parse("2024-03-10", time.date_only, "Asia/Tokyo").unix`, "1709996400"},
+		{`time.parse("10/03/2024", "2006-01-02")`, `ERROR: ` + "`time.parse`" + ` failed: parsing time "10/03/2024" as "2006-01-02": cannot parse "10/03/2024" as "2006"`},
+		{`time.from_unix(86400, "UTC")`, "1970-01-02T00:00:00Z"},
+		{`time.from_unix(1.5, "UTC")`, "1970-01-01T00:00:01.5Z"},
+		{`time.date(2024, 1, 31, "UTC") + time.duration("36h")`, "2024-02-01T12:00:00Z"},
+		{`time.date(2024, 1, 1, "UTC") - 90 * time.minute`, "2023-12-31T22:30:00Z"},
+		{`time.date(2024, 3, 1, "UTC") - time.date(2024, 2, 1, "UTC")`, "696h0m0s"},
+		{`(time.date(2024, 3, 1, "UTC") - time.date(2024, 2, 1, "UTC")) / time.hour`, "696"},
+		{`time.hour / 4 + time.second * 1.5`, "15m1.5s"},
+		{`time.duration(90).minutes`, "1.5"},
+		{`time.duration("1h30m") == 90 * time.minute`, "true"},
+		{`time.date(2024, 1, 1, "UTC") < time.date(2024, 1, 2, "UTC")`, "true"},
+		{`time.date(2024, 1, 1, 1, 0, 0, "Europe/Berlin") == time.date(2024, 1, 1, "UTC")`, "true"},
+		{`time.duration("soon")`, "ERROR: `time.duration` failed: time: invalid duration \"soon\""},
+		{`time.now("Mars/Olympus")`, "ERROR: unknown time zone in `time.now`: Mars/Olympus"},
+		{`time.hour / 0`, "ERROR: division by zero"},
+		{`time.hour + 1`, "ERROR: type mismatch: DURATION + INTEGER"},
+		{`time.date(2024, 1, 1, "UTC").days`, "ERROR: cannot access member days of TIME"},
+		{`time.date(2024, 1, 1, 0, 0, "x", "UTC")`, "ERROR: sixth argument to `time.date` must be INTEGER, got STRING"},
+		{`time.date(2024, 1, 1, 0, 0, 0, 0)`, "ERROR: seventh argument to `time.date` must be STRING, got INTEGER"},
+		{`start = time.clock(); time.sleep(0.01); time.clock() - start >= 0.01`, "true"},
+		{`time.now() - time.now() <= time.duration(0)`, "true"},
+	}
+
+	InspectTests(t, tests)
+}
+
+func TestProcessModule(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		input          string
+		expected       string
+		expectedStdout string
+	}{
+		{`process.run("echo", ["much", "wow"])`, "{stdout: much wow\n, stderr: , code: 0}", ""},
+		{`process.run("sh", ["-c", "echo oops >&2; exit 3"])`, "{stdout: , stderr: oops\n, code: 3}", ""},
+		{`process.run("cat", [], {"stdin": "such input"}).stdout`, "such input", ""},
+		{`process.run("pwd", [], {"cwd": "{dir}"}).stdout`, "{dir}\n", ""},
+		{`process.run("sh", ["-c", "echo $DOGE"], {"env": {"DOGE": "wow"}}).stdout`, "wow\n", ""},
+		{`process.run("sleep", ["5"], {"timeout": 0.05})`, "ERROR: `process.run` timed out after 50ms", ""},
+		{`process.run("does-not-exist-doge")`, "ERROR: `process.run` failed: exec: \"does-not-exist-doge\": executable file not found in $PATH", ""},
+		{`process.run("echo", [1])`, "ERROR: arguments for `process.run` must be STRING, got INTEGER", ""},
+		{`process.run("echo", [], {"shell": true})`, "ERROR: invalid value for `process.run` option shell: true", ""},
+		{`process.run("echo", [], {"env": {"A": 1}})`, "ERROR: environment variables for `process.run` must be STRING, got INTEGER", ""},
+		{`process.stream("sh", ["-c", "echo streamed; exit 2"])`, "2", "streamed\n"},
+		{`s = []; for (line in process.lines("printf", ["a\nb\nc"])) { append(s, line) }; s`, "[a, b, c]", ""},
+		{`for (line in process.lines("yes")) { break }; "stopped"`, "stopped", ""},
+		{`for (line in process.lines("sh", ["-c", "echo a; exit 1"])) { print(line) }`, "ERROR: `process.lines` failed: exit status 1", "a\n"},
+	}
+
+	for _, tt := range tests {
+		input := strings.ReplaceAll(tt.input, "{dir}", dir)
+		expected := strings.ReplaceAll(tt.expected, "{dir}", dir)
+
+		evaluated, stdout, _ := EvalTestWithOutput(input)
+		InspectObjectTest(t, input, evaluated, expected)
+		if stdout != tt.expectedStdout {
+			t.Errorf("wrong stdout for %q. expected=%q, got=%q", input, tt.expectedStdout, stdout)
+		}
+	}
+}
+
+func TestProcessTimeoutKillsChildren(t *testing.T) {
+	for _, input := range []string{
+		`process.run("sh", ["-c", "sleep 5; echo hi"], {"timeout": 0.2})`,
+		`for (line in process.lines("sh", ["-c", "sleep 5; echo hi"], {"timeout": 0.2})) { }`,
+	} {
+		start := time.Now()
+		evaluated := EvalTest(input)
+		if _, ok := evaluated.(*object.Error); !ok {
+			t.Errorf("expected timeout error for %q, got=%+v", input, evaluated)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%q took %s, the child of the shell kept running", input, elapsed)
+		}
+	}
+}
+
+func TestDisabledModules(t *testing.T) {
+	input := `[math.abs(-1), try(process.run, "echo")]`
+	program := parser.New(lexer.New(input)).ParseProgram()
+	env := object.NewEnvironment()
+	env.Context().DisabledModules = map[string]bool{"process": true}
+	InitBuiltins()
+
+	InspectObjectTest(t, input, Eval(program, env), "ERROR: module process is disabled")
+}
+
+func TestHttpModule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.Header().Set("X-Doge", "wow")
+			fmt.Fprintf(w, "%s %s %s %s %s", r.Method, r.URL.RequestURI(), r.Header.Get("X-Token"), r.Header.Get("Content-Type"), body)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`http.get("{url}/such?a=1").body`, "GET /such?a=1   "},
+		{`r = http.get("{url}/such"); [r.status, r.headers["x-doge"]]`, "[200, wow]"},
+		{`http.get("{url}", {"headers": {"X-Token": "secret"}}).body`, "GET / secret  "},
+		{`http.post("{url}/doge", "much data").body`, "POST /doge   much data"},
+		{`http.post("{url}/doge", {"much": [1, 2]}).body`, `POST /doge  application/json {"much":[1,2]}`},
+		{`http.request("put", "{url}/x", {"body": "wow", "headers": {"Content-Type": "text/plain"}}).body`, "PUT /x  text/plain wow"},
+		{`http.request("DELETE", "{url}/x").body`, "DELETE /x   "},
+		{`http.get("{url}/missing").status`, "404"},
+		{`http.get("{url}/slow", {"timeout": 0.05})`, "ERROR: `http.get` failed: Get \"{url}/slow\": context deadline exceeded (Client.Timeout exceeded while awaiting headers)"},
+		{`http.get("{url}", {"retries": 3})`, "ERROR: unknown option for `http.get`: retries"},
+		{`http.get("{url}", {"headers": {"X-Count": 1}})`, "ERROR: headers for `http.get` must be STRING, got INTEGER"},
+		{`http.get(1)`, "ERROR: first argument to `http.get` must be STRING, got INTEGER"},
+		{`http.serve("::1::", r => "")`, "ERROR: `http.serve` failed: listen tcp: address ::1::: too many colons in address"},
+	}
+
+	for _, tt := range tests {
+		input := strings.ReplaceAll(tt.input, "{url}", server.URL)
+		expected := strings.ReplaceAll(tt.expected, "{url}", server.URL)
+
+		InspectObjectTest(t, input, EvalTest(input), expected)
+	}
+}
+
+func TestHttpHandler(t *testing.T) {
+	handler := `r => match (r.path) {
+		case "/hello" => "hello " + r.query["name"]
+		case "/echo" => ({"status": 201, "headers": {"X-Method": r.method}, "body": {"got": r.body, "type": r.headers["content-type"]}})
+		case "/empty" => ({"status": 204})
+		case "/bad" => 42
+		case "/exit" => os.exit(3)
+		case _ => ({"status": 404, "body": "not found"})
+	}`
+
+	var stderr bytes.Buffer
+	env := object.NewEnvironment()
+	env.Context().Stderr = &stderr
+	InitBuiltins()
+	fn := Eval(parser.New(lexer.New(handler)).ParseProgram(), env)
+	if IsError(fn) {
+		t.Fatalf("handler failed to evaluate: %s", fn.Inspect())
+	}
+
+	var exit *object.Error
+	server := httptest.NewServer(NewHTTPHandler(fn, env, func(err *object.Error) { exit = err }))
+	defer server.Close()
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		contentType        string
+		expected           string
+	}{
+		{"GET", "/hello?name=doge", "", 200, "text/plain; charset=utf-8", "hello doge"},
+		{"POST", "/echo", "wow", 201, "application/json", `{"got":"wow","type":"text/plain"}`},
+		{"GET", "/empty", "", 204, "", ""},
+		{"GET", "/nope", "", 404, "text/plain; charset=utf-8", "not found"},
+		{"GET", "/bad", "", 500, "text/plain; charset=utf-8", "internal server error\n"},
+		{"GET", "/exit", "", 503, "text/plain; charset=utf-8", "service unavailable\n"},
+		{"GET", "/hello?name=doge", "", 503, "text/plain; charset=utf-8", "service unavailable\n"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "text/plain")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request to %s failed: %s", tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status || resp.Header.Get("Content-Type") != tt.contentType || string(body) != tt.expected {
+			t.Errorf("wrong response for %s %s. expected=%d %q %q, got=%d %q %q", tt.method, tt.path,
+				tt.status, tt.contentType, tt.expected, resp.StatusCode, resp.Header.Get("Content-Type"), body)
+		}
+	}
+
+	expectedStderr := "ERROR: handler for `http.serve` must return STRING or HASH, got INTEGER\n"
+	if stderr.String() != expectedStderr {
+		t.Errorf("wrong stderr. expected=%q, got=%q", expectedStderr, stderr.String())
+	}
+	if exit == nil || !exit.Exit || exit.Code != 3 {
+		t.Errorf("expected exit with code 3, got=%+v", exit)
+	}
+}
+
+func TestHttpServeExit(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %s", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	result := make(chan object.Object, 1)
+	go func() {
+		result <- EvalTest(`http.serve("` + addr + `", r => os.exit(4))`)
+	}()
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get("http://" + addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("server didn't start: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("wrong status. expected=%d, got=%d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	select {
+	case evaluated := <-result:
+		exit, ok := evaluated.(*object.Error)
+		if !ok || !exit.Exit || exit.Code != 4 {
+			t.Errorf("expected exit with code 4, got=%+v", evaluated)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("server kept running after os.exit")
+	}
+}
+
+func TestHttpDefaultTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	defer func(timeout time.Duration) { defaultHTTPTimeout = timeout }(defaultHTTPTimeout)
+	defaultHTTPTimeout = 50 * time.Millisecond
+
+	input := `http.get("` + server.URL + `")`
+	expected := "ERROR: `http.get` failed: Get \"" + server.URL + "\": context deadline exceeded (Client.Timeout exceeded while awaiting headers)"
+	InspectObjectTest(t, input, EvalTest(input), expected)
+
+	input = `http.get("` + server.URL + `", {"timeout": 0}).status`
+	InspectObjectTest(t, input, EvalTest(input), "200")
+}
@@ -0,0 +1,223 @@
+package evaluator
+
+import (
+	"doge/object"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxFormatWidth = 1000000
+
+// FormatSpec is a parsed format directive. The same mini-language is used by
+// f-strings (`{x:08.2f}`) and the `format` builtin (`%08.2f`):
+//
+//	[flags][width][.precision][verb]
+//
+// Flags:
+//
+//	0    pad numbers with zeros instead of spaces
+//	-    align left
+//	^    center
+//	+    always print the sign of numbers
+//	' '  leave a space for the sign of positive numbers
+//	#    alternate form, e.g. 0x prefix for hex
+//
+// Verbs:
+//
+//	v    any value in its default format (default)
+//	d    integer
+//	f    float with fixed decimals
+//	e E  float in scientific notation
+//	g G  float in the shortest of %e and %f
+//	x X  integer or string in hex
+//	o    integer in octal
+//	b    integer in binary
+//	c    character for an integer code point
+//	s    string, other values as they are printed
+type FormatSpec struct {
+	Flags     string
+	Width     int
+	Precision int
+	Verb      byte
+}
+
+func ParseFormatSpec(spec string) (FormatSpec, bool) {
+	fs := FormatSpec{Width: -1, Precision: -1, Verb: 'v'}
+	i := 0
+
+	for i < len(spec) && strings.IndexByte("-^0+ #", spec[i]) >= 0 {
+		fs.Flags += string(spec[i])
+		i++
+	}
+
+	start := i
+	for i < len(spec) && IsDigitByte(spec[i]) {
+		i++
+	}
+	if i > start {
+		fmt.Sscan(spec[start:i], &fs.Width)
+	}
+
+	if i < len(spec) && spec[i] == '.' {
+		i++
+		start = i
+		for i < len(spec) && IsDigitByte(spec[i]) {
+			i++
+		}
+		fs.Precision = 0
+		if i > start {
+			fmt.Sscan(spec[start:i], &fs.Precision)
+		}
+	}
+
+	if i < len(spec) {
+		fs.Verb = spec[i]
+		i++
+	}
+
+	if i != len(spec) || strings.IndexByte("vdfeEgGxXobcs", fs.Verb) < 0 {
+		return fs, false
+	}
+
+	return fs, true
+}
+
+// FormatObject formats obj according to spec, see FormatSpec. An empty spec
+// gives the same result as string(obj).
+func FormatObject(obj object.Object, spec string) (string, *object.Error) {
+	fs, ok := ParseFormatSpec(spec)
+	if !ok {
+		return "", NewError("invalid format spec %q", spec)
+	}
+
+	return fs.Format(obj)
+}
+
+func (fs FormatSpec) Format(obj object.Object) (string, *object.Error) {
+	var verb byte
+	var value interface{}
+
+	// the fmt package doesn't pad further either
+	if fs.Width > maxFormatWidth || fs.Precision > maxFormatWidth {
+		return "", NewError("format width and precision must be at most %d", maxFormatWidth)
+	}
+
+	switch fs.Verb {
+	case 'd', 'o', 'b', 'c':
+		integer, ok := obj.(*object.Integer)
+		if !ok {
+			return "", NewError("format verb %%%c needs INTEGER, got %s", fs.Verb, obj.Type())
+		}
+		verb, value = fs.Verb, integer.Value
+	case 'x', 'X':
+		switch obj := obj.(type) {
+		case *object.Integer:
+			value = obj.Value
+		case *object.String:
+			value = obj.Value
+		default:
+			return "", NewError("format verb %%%c needs INTEGER or STRING, got %s", fs.Verb, obj.Type())
+		}
+		verb = fs.Verb
+	case 'f', 'e', 'E', 'g', 'G':
+		if !IsNumeric(obj) {
+			return "", NewError("format verb %%%c needs a number, got %s", fs.Verb, obj.Type())
+		}
+		verb, value = fs.Verb, ObjectToFloat(obj)
+	case 's':
+		verb, value = 's', obj.Inspect()
+	default:
+		switch obj := obj.(type) {
+		case *object.Integer:
+			verb, value = 'd', obj.Value
+		case *object.Float:
+			if fs.Precision >= 0 {
+				verb, value = 'f', obj.Value
+			} else {
+				verb, value = 's', obj.Inspect()
+			}
+		default:
+			verb, value = 's', obj.Inspect()
+		}
+	}
+
+	flags := strings.Replace(fs.Flags, "^", "", -1)
+	if verb == 's' || verb == 'c' {
+		// zero padding only applies to numbers
+		flags = strings.Replace(flags, "0", "", -1)
+	}
+
+	directive := "%" + flags
+	if fs.Width >= 0 && !fs.Centered() {
+		directive += fmt.Sprint(fs.Width)
+	}
+	if fs.Precision >= 0 {
+		directive += "." + fmt.Sprint(fs.Precision)
+	}
+	directive += string(verb)
+
+	out := fmt.Sprintf(directive, value)
+
+	if length := utf8.RuneCountInString(out); fs.Centered() && length < fs.Width {
+		padding := fs.Width - length
+		out = strings.Repeat(" ", padding/2) + out + strings.Repeat(" ", padding-padding/2)
+	}
+
+	return out, nil
+}
+
+// FormatString replaces the `%` directives in format with the formatted
+// arguments, see FormatSpec. `%%` is a literal percent sign.
+func FormatString(format string, args []object.Object) (string, *object.Error) {
+	var out strings.Builder
+	argIdx := 0
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+
+		if i+1 < len(format) && format[i+1] == '%' {
+			out.WriteByte('%')
+			i++
+			continue
+		}
+
+		end := i + 1
+		for end < len(format) && strings.IndexByte("-^0+ #.0123456789", format[end]) >= 0 {
+			end++
+		}
+		if end == len(format) {
+			return "", NewError("incomplete format directive %q", format[i:])
+		}
+
+		if argIdx >= len(args) {
+			return "", NewError("missing argument for format directive %q", format[i:end+1])
+		}
+
+		formatted, err := FormatObject(args[argIdx], format[i+1:end+1])
+		if err != nil {
+			return "", err
+		}
+
+		out.WriteString(formatted)
+		argIdx++
+		i = end
+	}
+
+	if argIdx < len(args) {
+		return "", NewError("too many arguments for format. got=%d, want=%d", len(args), argIdx)
+	}
+
+	return out.String(), nil
+}
+
+func (fs FormatSpec) Centered() bool {
+	return strings.Contains(fs.Flags, "^")
+}
+
+func IsDigitByte(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
@@ -0,0 +1,330 @@
+package evaluator
+
+import (
+	"bufio"
+	"doge/object"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// File system errors are ordinary error objects, so scripts can handle them
+// with `try`, e.g. `content, err = try(fs.read_file, "input.txt")`.
+func NewFsModule() *object.Module {
+	members := map[string]object.Object{
+		"read_file": &object.Builtin{
+			Fn:            fsReadFile,
+			Documentation: "This function returns the contents of a file as a string!",
+		},
+		"write_file": &object.Builtin{
+			Fn:            fsWriteFile("fs.write_file", os.O_TRUNC),
+			Documentation: "This function writes a string to a file, replacing its contents!",
+		},
+		"append_file": &object.Builtin{
+			Fn:            fsWriteFile("fs.append_file", os.O_APPEND),
+			Documentation: "This function appends a string to a file!",
+		},
+		"read_lines": &object.Builtin{
+			Fn:            fsReadLines,
+			Documentation: "This function returns the lines of a file as an array, without line endings!",
+		},
+		"lines": &object.Builtin{
+			Fn:            fsLines,
+			Documentation: "This function returns an iterator over the lines of a file, reading one line at a time!",
+		},
+		"exists": &object.Builtin{
+			Fn:            fsExists,
+			Documentation: "This function checks if a file or directory exists!",
+		},
+		"listdir": &object.Builtin{
+			Fn:            fsListdir,
+			Documentation: "This function returns the sorted names in a directory, the current one by default!",
+		},
+		"mkdir": &object.Builtin{
+			Fn:            fsMkdir,
+			Documentation: "This function creates a directory along with any missing parents!",
+		},
+		"remove": &object.Builtin{
+			Fn:            fsRemove,
+			Documentation: "This function removes a file or an empty directory!",
+		},
+		"rename": &object.Builtin{
+			Fn:            fsRename,
+			Documentation: "This function renames or moves a file or directory. Usage: rename(old, new)",
+		},
+		"stat": &object.Builtin{
+			Fn:            fsStat,
+			Documentation: "This function returns a hash with the name, size, is_dir, mode and modified time of a file!",
+		},
+		"glob": &object.Builtin{
+			Fn:            fsGlob,
+			Documentation: "This function returns the sorted paths matching a pattern like \"data/*.txt\"!",
+		},
+	}
+
+	return &object.Module{Name: "fs", Members: members}
+}
+
+func FsError(name string, err error) *object.Error {
+	return NewError("`%s` failed: %s", name, err)
+}
+
+func pathArgument(name string, args []object.Object) (string, *object.Error) {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return "", err
+	}
+
+	return StringArgument(name, args, 0)
+}
+
+func fsReadFile(env *object.Environment, args ...object.Object) object.Object {
+	path, err := pathArgument("fs.read_file", args)
+	if err != nil {
+		return err
+	}
+
+	buf, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return FsError("fs.read_file", readErr)
+	}
+
+	return &object.String{Value: string(buf)}
+}
+
+func fsWriteFile(name string, mode int) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 2, 2); err != nil {
+			return err
+		}
+		path, err := StringArgument(name, args, 0)
+		if err != nil {
+			return err
+		}
+		content, err := StringArgument(name, args, 1)
+		if err != nil {
+			return err
+		}
+
+		file, openErr := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|mode, 0644)
+		if openErr != nil {
+			return FsError(name, openErr)
+		}
+
+		_, writeErr := file.WriteString(content)
+		if closeErr := file.Close(); writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr != nil {
+			return FsError(name, writeErr)
+		}
+
+		return NULL
+	}
+}
+
+// SplitLines splits text into lines, dropping the line endings and the empty
+// line after a final newline.
+func SplitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return lines
+}
+
+func fsReadLines(env *object.Environment, args ...object.Object) object.Object {
+	path, err := pathArgument("fs.read_lines", args)
+	if err != nil {
+		return err
+	}
+
+	buf, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return FsError("fs.read_lines", readErr)
+	}
+
+	return StringsToArray(SplitLines(string(buf)))
+}
+
+// LineIterator returns an iterator over the lines read from r, without line
+// endings. close is called once the lines are exhausted or iteration stops.
+func LineIterator(name string, r io.Reader, close func()) *object.Iterator {
+	// reuse a shared reader like the context's stdin, so no input is lost
+	reader, ok := r.(*bufio.Reader)
+	if !ok {
+		reader = bufio.NewReader(r)
+	}
+	done := false
+
+	finish := func() {
+		if !done {
+			done = true
+			if close != nil {
+				close()
+			}
+		}
+	}
+
+	next := func() (object.Object, bool) {
+		if done {
+			return nil, false
+		}
+
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			finish()
+			return FsError(name, err), true
+		}
+		if err == io.EOF {
+			finish()
+			if line == "" {
+				return nil, false
+			}
+		}
+
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		return &object.String{Value: line}, true
+	}
+
+	return &object.Iterator{Name: name, Next: next, Close: finish}
+}
+
+func fsLines(env *object.Environment, args ...object.Object) object.Object {
+	path, err := pathArgument("fs.lines", args)
+	if err != nil {
+		return err
+	}
+
+	file, openErr := os.Open(path)
+	if openErr != nil {
+		return FsError("fs.lines", openErr)
+	}
+
+	return LineIterator("fs.lines", file, func() { file.Close() })
+}
+
+func fsExists(env *object.Environment, args ...object.Object) object.Object {
+	path, err := pathArgument("fs.exists", args)
+	if err != nil {
+		return err
+	}
+
+	_, statErr := os.Stat(path)
+
+	return NativeBoolToBooleanObject(statErr == nil)
+}
+
+func fsListdir(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 1); err != nil {
+		return err
+	}
+
+	path := "."
+	if len(args) == 1 {
+		var err *object.Error
+		if path, err = StringArgument("fs.listdir", args, 0); err != nil {
+			return err
+		}
+	}
+
+	entries, readErr := os.ReadDir(path)
+	if readErr != nil {
+		return FsError("fs.listdir", readErr)
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+
+	return StringsToArray(names)
+}
+
+func fsMkdir(env *object.Environment, args ...object.Object) object.Object {
+	path, err := pathArgument("fs.mkdir", args)
+	if err != nil {
+		return err
+	}
+
+	if mkdirErr := os.MkdirAll(path, 0755); mkdirErr != nil {
+		return FsError("fs.mkdir", mkdirErr)
+	}
+
+	return NULL
+}
+
+func fsRemove(env *object.Environment, args ...object.Object) object.Object {
+	path, err := pathArgument("fs.remove", args)
+	if err != nil {
+		return err
+	}
+
+	if removeErr := os.Remove(path); removeErr != nil {
+		return FsError("fs.remove", removeErr)
+	}
+
+	return NULL
+}
+
+func fsRename(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	from, err := StringArgument("fs.rename", args, 0)
+	if err != nil {
+		return err
+	}
+	to, err := StringArgument("fs.rename", args, 1)
+	if err != nil {
+		return err
+	}
+
+	if renameErr := os.Rename(from, to); renameErr != nil {
+		return FsError("fs.rename", renameErr)
+	}
+
+	return NULL
+}
+
+func fsStat(env *object.Environment, args ...object.Object) object.Object {
+	path, err := pathArgument("fs.stat", args)
+	if err != nil {
+		return err
+	}
+
+	info, statErr := os.Stat(path)
+	if statErr != nil {
+		return FsError("fs.stat", statErr)
+	}
+
+	stat := object.NewHash()
+	stat.Set(&object.String{Value: "name"}, &object.String{Value: info.Name()})
+	stat.Set(&object.String{Value: "size"}, &object.Integer{Value: info.Size()})
+	stat.Set(&object.String{Value: "is_dir"}, NativeBoolToBooleanObject(info.IsDir()))
+	stat.Set(&object.String{Value: "mode"}, &object.String{Value: info.Mode().String()})
+	stat.Set(&object.String{Value: "modified"}, &object.Integer{Value: info.ModTime().Unix()})
+
+	return stat
+}
+
+func fsGlob(env *object.Environment, args ...object.Object) object.Object {
+	pattern, err := pathArgument("fs.glob", args)
+	if err != nil {
+		return err
+	}
+
+	matches, globErr := filepath.Glob(pattern)
+	if globErr != nil {
+		return FsError("fs.glob", globErr)
+	}
+	sort.Strings(matches)
+
+	return StringsToArray(matches)
+}
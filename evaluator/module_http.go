@@ -0,0 +1,396 @@
+package evaluator
+
+import (
+	"context"
+	"doge/object"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Responses are hashes with the keys status, headers and body. Header names
+// are lower case, so `resp.headers["content-type"]` finds them however the
+// server spelled them. A status like 404 is not an error, but failing to
+// connect or running into the timeout is. Requests time out after 30 seconds
+// unless the timeout option says otherwise, and a timeout of 0 waits forever.
+//
+// Bodies that aren't strings are sent as JSON, both by the client functions
+// and by handlers of `http.serve`.
+func NewHttpModule() *object.Module {
+	members := map[string]object.Object{
+		"get": &object.Builtin{
+			Fn:            httpGet,
+			Documentation: "This function sends a GET request and returns the response. Usage: get(url, {\"headers\": {...}, \"timeout\": seconds})",
+		},
+		"post": &object.Builtin{
+			Fn:            httpPost,
+			Documentation: "This function sends a POST request with a body and returns the response. Usage: post(url, body, {\"headers\": {...}, \"timeout\": seconds})",
+		},
+		"request": &object.Builtin{
+			Fn:            httpRequest,
+			Documentation: "This function sends a request with any method and returns the response. Usage: request(method, url, {\"headers\": {...}, \"body\": body, \"timeout\": seconds})",
+		},
+		"serve": &object.Builtin{
+			Fn:            httpServe,
+			Documentation: "This function serves HTTP on an address like \":8080\", calling a function with a hash of method, path, query, headers and body for every request. It returns a string, or a hash of status, headers and body, and the server stops when it calls os.exit!",
+		},
+	}
+
+	return &object.Module{Name: "http", Members: members}
+}
+
+// defaultHTTPTimeout is the timeout of requests without a timeout option.
+var defaultHTTPTimeout = 30 * time.Second
+
+// shutdownTimeout is how long `http.serve` waits for the requests it is
+// handling when a handler exits the program.
+const shutdownTimeout = 5 * time.Second
+
+// httpOptions hold the headers and body of a request or a response, and the
+// timeout of a request.
+type httpOptions struct {
+	headers     http.Header
+	body        string
+	contentType string
+	timeout     time.Duration
+}
+
+func (o *httpOptions) setBody(name string, body object.Object) *object.Error {
+	if str, ok := body.(*object.String); ok {
+		o.body = str.Value
+		return nil
+	}
+
+	enc := &jsonEncoder{}
+	if err := enc.encode(body, "$", 0); err != nil {
+		return err
+	}
+	o.body = enc.out.String()
+	o.contentType = "application/json"
+
+	return nil
+}
+
+func (o *httpOptions) configure(name string, options *object.Hash) *object.Error {
+	for _, pair := range options.Ordered() {
+		key, _ := pair.Key.(*object.String)
+		if key == nil {
+			return NewError("unknown option for `%s`: %s", name, pair.Key.Inspect())
+		}
+
+		switch key.Value {
+		case "headers":
+			headers, ok := pair.Value.(*object.Hash)
+			if !ok {
+				return NewError("invalid value for `%s` option %s: %s", name, key.Value, pair.Value.Inspect())
+			}
+			if err := setHeaders(name, o.headers, headers); err != nil {
+				return err
+			}
+		case "body":
+			if err := o.setBody(name, pair.Value); err != nil {
+				return err
+			}
+		case "timeout":
+			timeout, err := DurationArgument(name, []object.Object{pair.Value}, 0)
+			if err != nil {
+				return NewError("invalid value for `%s` option %s: %s", name, key.Value, pair.Value.Inspect())
+			}
+			o.timeout = timeout
+		default:
+			return NewError("unknown option for `%s`: %s", name, key.Value)
+		}
+	}
+
+	return nil
+}
+
+func setHeaders(name string, header http.Header, headers *object.Hash) *object.Error {
+	for _, pair := range headers.Ordered() {
+		for _, part := range []object.Object{pair.Key, pair.Value} {
+			if part.Type() != object.STRING_OBJ {
+				return NewError("headers for `%s` must be STRING, got %s", name, part.Type())
+			}
+		}
+		header.Set(pair.Key.Inspect(), pair.Value.Inspect())
+	}
+
+	return nil
+}
+
+// HeadersToHash converts headers to a hash with lower case names sorted by
+// name, joining repeated headers with commas.
+func HeadersToHash(header http.Header) *object.Hash {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := object.NewHash()
+	for _, name := range names {
+		hash.Set(&object.String{Value: strings.ToLower(name)}, &object.String{Value: strings.Join(header[name], ", ")})
+	}
+
+	return hash
+}
+
+// sendRequest sends a request, taking the options from the argument at idx.
+func sendRequest(name, method, url string, body object.Object, args []object.Object, idx int) object.Object {
+	opts := &httpOptions{headers: http.Header{}, timeout: defaultHTTPTimeout}
+	if body != nil {
+		if err := opts.setBody(name, body); err != nil {
+			return err
+		}
+	}
+	if len(args) > idx {
+		options, err := HashArgument(name, args, idx)
+		if err != nil {
+			return err
+		}
+		if err := opts.configure(name, options); err != nil {
+			return err
+		}
+	}
+
+	req, reqErr := http.NewRequest(method, url, strings.NewReader(opts.body))
+	if reqErr != nil {
+		return FsError(name, reqErr)
+	}
+	req.Header = opts.headers
+	if opts.contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", opts.contentType)
+	}
+
+	client := &http.Client{Timeout: opts.timeout}
+	resp, respErr := client.Do(req)
+	if respErr != nil {
+		return FsError(name, respErr)
+	}
+	defer resp.Body.Close()
+
+	content, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return FsError(name, readErr)
+	}
+
+	result := object.NewHash()
+	result.Set(&object.String{Value: "status"}, &object.Integer{Value: int64(resp.StatusCode)})
+	result.Set(&object.String{Value: "headers"}, HeadersToHash(resp.Header))
+	result.Set(&object.String{Value: "body"}, &object.String{Value: string(content)})
+
+	return result
+}
+
+func httpGet(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	url, err := StringArgument("http.get", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return sendRequest("http.get", http.MethodGet, url, nil, args, 1)
+}
+
+func httpPost(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 3); err != nil {
+		return err
+	}
+	url, err := StringArgument("http.post", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return sendRequest("http.post", http.MethodPost, url, args[1], args, 2)
+}
+
+func httpRequest(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 3); err != nil {
+		return err
+	}
+	method, err := StringArgument("http.request", args, 0)
+	if err != nil {
+		return err
+	}
+	url, err := StringArgument("http.request", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return sendRequest("http.request", strings.ToUpper(method), url, nil, args, 2)
+}
+
+func httpServe(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	addr, err := StringArgument("http.serve", args, 0)
+	if err != nil {
+		return err
+	}
+	fn, err := FunctionArgument("http.serve", args, 1)
+	if err != nil {
+		return err
+	}
+
+	exit := make(chan *object.Error, 1)
+	server := &http.Server{Addr: addr, Handler: NewHTTPHandler(fn, env, func(err *object.Error) {
+		exit <- err
+	})}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ListenAndServe()
+	}()
+
+	select {
+	case serveErr := <-served:
+		return FsError("http.serve", serveErr)
+	case err := <-exit:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		server.Shutdown(ctx)
+		return err
+	}
+}
+
+// httpHandler calls a doge function for every request. The evaluator is not
+// safe for concurrent use, so requests are handled one at a time.
+type httpHandler struct {
+	mu     sync.Mutex
+	fn     object.Object
+	env    *object.Environment
+	onExit func(*object.Error)
+	exited bool
+}
+
+// NewHTTPHandler returns a handler calling fn like `http.serve` does, for
+// hosts serving doge functions from their own servers. When fn exits the
+// program with `os.exit`, onExit is called once with the exit error, and
+// this and all later requests get a 503 response. onExit may be nil.
+func NewHTTPHandler(fn object.Object, env *object.Environment, onExit func(*object.Error)) http.Handler {
+	return &httpHandler{fn: fn, env: env, onExit: onExit}
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, readErr := io.ReadAll(r.Body)
+	if readErr != nil {
+		http.Error(w, readErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values := r.URL.Query()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	query := object.NewHash()
+	for _, name := range names {
+		query.Set(&object.String{Value: name}, &object.String{Value: values.Get(name)})
+	}
+
+	request := object.NewHash()
+	request.Set(&object.String{Value: "method"}, &object.String{Value: r.Method})
+	request.Set(&object.String{Value: "path"}, &object.String{Value: r.URL.Path})
+	request.Set(&object.String{Value: "query"}, query)
+	request.Set(&object.String{Value: "headers"}, HeadersToHash(r.Header))
+	request.Set(&object.String{Value: "body"}, &object.String{Value: string(body)})
+
+	// the result is converted under the lock too, as it may be shared with
+	// other requests
+	h.mu.Lock()
+	if h.exited {
+		h.mu.Unlock()
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	status, opts, err := buildResponse(ApplyFunction(h.fn, []object.Object{request}, h.env))
+	exited := err != nil && err.Exit
+	if exited {
+		h.exited = true
+		if h.onExit != nil {
+			h.onExit(err)
+		}
+	} else if err != nil {
+		io.WriteString(h.env.Context().Stderr, err.Inspect()+"\n")
+	}
+	h.mu.Unlock()
+
+	if exited {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	for name, values := range opts.headers {
+		w.Header()[name] = values
+	}
+	if opts.contentType != "" && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", opts.contentType)
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, opts.body)
+}
+
+// buildResponse converts what a handler returned to the status, headers and
+// body of the response.
+func buildResponse(result object.Object) (int, *httpOptions, *object.Error) {
+	if err, ok := result.(*object.Error); ok {
+		return 0, nil, err
+	}
+
+	status := int64(http.StatusOK)
+	var body object.Object = result
+	opts := &httpOptions{headers: http.Header{}}
+
+	switch result := result.(type) {
+	case *object.String, *object.Null:
+	case *object.Hash:
+		body = &object.String{}
+		for _, pair := range result.Ordered() {
+			key, _ := pair.Key.(*object.String)
+			if key == nil {
+				return 0, nil, NewError("unknown key in response of `http.serve` handler: %s", pair.Key.Inspect())
+			}
+
+			switch key.Value {
+			case "status":
+				code, ok := pair.Value.(*object.Integer)
+				if !ok || code.Value < 100 || code.Value > 999 {
+					return 0, nil, NewError("invalid status in response of `http.serve` handler: %s", pair.Value.Inspect())
+				}
+				status = code.Value
+			case "headers":
+				headers, ok := pair.Value.(*object.Hash)
+				if !ok {
+					return 0, nil, NewError("invalid headers in response of `http.serve` handler: %s", pair.Value.Inspect())
+				}
+				if err := setHeaders("http.serve", opts.headers, headers); err != nil {
+					return 0, nil, err
+				}
+			case "body":
+				body = pair.Value
+			default:
+				return 0, nil, NewError("unknown key in response of `http.serve` handler: %s", key.Value)
+			}
+		}
+	default:
+		return 0, nil, NewError("handler for `http.serve` must return STRING or HASH, got %s", result.Type())
+	}
+
+	if body.Type() != object.NULL_OBJ {
+		if err := opts.setBody("http.serve", body); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return int(status), opts, nil
+}
@@ -0,0 +1,324 @@
+package evaluator
+
+import (
+	"bytes"
+	"doge/object"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func NewJsonModule() *object.Module {
+	members := map[string]object.Object{
+		"parse": &object.Builtin{
+			Fn:            jsonParse,
+			Documentation: "This function parses a JSON string into hashes, arrays, strings, numbers, booleans and null!",
+		},
+		"stringify": &object.Builtin{
+			Fn:            jsonStringify,
+			Documentation: "This function serializes a value to JSON. Usage: stringify(value, {\"indent\": 2, \"sort_keys\": true})",
+		},
+	}
+
+	return &object.Module{Name: "json", Members: members}
+}
+
+func jsonParse(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	text, err := StringArgument("json.parse", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return ParseJSON(text)
+}
+
+// ParseJSON converts JSON text to objects. Objects become hashes that keep
+// the key order of the text, and numbers without a fraction or exponent
+// become INTEGERs.
+func ParseJSON(text string) object.Object {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+
+	value, err := decodeJSONValue(dec)
+	offset := dec.InputOffset()
+
+	if err == nil {
+		rest := text[offset:]
+		trimmed := strings.TrimLeft(rest, " \t\r\n")
+		if trimmed == "" {
+			return value
+		}
+		offset += int64(len(rest) - len(trimmed))
+		err = fmt.Errorf("unexpected data after JSON value")
+	} else if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		// the offending byte is the last one the decoder read, unless the
+		// input ended early
+		offset = syntaxErr.Offset
+		if syntaxErr.Error() != "unexpected end of JSON input" {
+			offset--
+		}
+	} else if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = fmt.Errorf("unexpected end of JSON input")
+	}
+
+	line, column := TextPosition(text, offset)
+	return NewError("invalid JSON at line %d, column %d: %s", line, column, err)
+}
+
+func decodeJSONValue(dec *json.Decoder) (object.Object, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			arr := &object.Array{Elements: []object.Object{}}
+			for dec.More() {
+				el, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr.Elements = append(arr.Elements, el)
+			}
+			_, err := dec.Token()
+			return arr, err
+		}
+
+		hash := object.NewHash()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(&object.String{Value: key.(string)}, value)
+		}
+		_, err := dec.Token()
+		return hash, err
+	case string:
+		return &object.String{Value: tok}, nil
+	case json.Number:
+		if i, err := tok.Int64(); err == nil {
+			return &object.Integer{Value: i}, nil
+		}
+		f, err := tok.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("number out of range: %s", tok)
+		}
+		return &object.Float{Value: f}, nil
+	case bool:
+		return NativeBoolToBooleanObject(tok), nil
+	default:
+		return NULL, nil
+	}
+}
+
+// TextPosition converts a byte offset into a 1-based line and column.
+func TextPosition(text string, offset int64) (int, int) {
+	if offset > int64(len(text)) {
+		offset = int64(len(text))
+	}
+
+	before := text[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := len(before) - strings.LastIndex(before, "\n")
+
+	return line, column
+}
+
+func jsonStringify(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+
+	enc := &jsonEncoder{}
+	if len(args) == 2 {
+		options, err := HashArgument("json.stringify", args, 1)
+		if err != nil {
+			return err
+		}
+		if err := enc.configure(options); err != nil {
+			return err
+		}
+	}
+
+	if err := enc.encode(args[0], "$", 0); err != nil {
+		return err
+	}
+
+	return &object.String{Value: enc.out.String()}
+}
+
+type jsonEncoder struct {
+	out      bytes.Buffer
+	indent   string
+	sortKeys bool
+	// path holds the arrays and hashes being encoded, to detect cycles
+	path []object.Object
+}
+
+func (e *jsonEncoder) configure(options *object.Hash) *object.Error {
+	for _, pair := range options.Ordered() {
+		key, _ := pair.Key.(*object.String)
+		if key == nil {
+			return NewError("unknown option for `json.stringify`: %s", pair.Key.Inspect())
+		}
+
+		switch value := pair.Value.(type) {
+		case *object.Integer:
+			if key.Value != "indent" || value.Value < 0 {
+				return NewError("invalid value for `json.stringify` option %s: %s", key.Value, value.Inspect())
+			}
+			e.indent = strings.Repeat(" ", int(value.Value))
+		case *object.String:
+			if key.Value != "indent" {
+				return NewError("invalid value for `json.stringify` option %s: %s", key.Value, value.Inspect())
+			}
+			e.indent = value.Value
+		case *object.Boolean:
+			if key.Value != "sort_keys" {
+				return NewError("invalid value for `json.stringify` option %s: %s", key.Value, value.Inspect())
+			}
+			e.sortKeys = value.Value
+		default:
+			return NewError("invalid value for `json.stringify` option %s: %s", key.Value, value.Inspect())
+		}
+	}
+
+	return nil
+}
+
+func (e *jsonEncoder) newline(depth int) {
+	if e.indent != "" {
+		e.out.WriteByte('\n')
+		e.out.WriteString(strings.Repeat(e.indent, depth))
+	}
+}
+
+func (e *jsonEncoder) enter(obj object.Object, at string) *object.Error {
+	for _, seen := range e.path {
+		if seen == obj {
+			return NewError("cannot serialize cyclic structure to JSON at %s", at)
+		}
+	}
+	e.path = append(e.path, obj)
+
+	return nil
+}
+
+func (e *jsonEncoder) encode(obj object.Object, at string, depth int) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Null:
+		e.out.WriteString("null")
+	case *object.Boolean:
+		e.out.WriteString(strconv.FormatBool(obj.Value))
+	case *object.Integer:
+		e.out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return NewError("cannot serialize %s to JSON at %s", obj.Inspect(), at)
+		}
+		num, _ := json.Marshal(obj.Value)
+		e.out.Write(num)
+		if !bytes.ContainsAny(num, ".eE") {
+			e.out.WriteString(".0")
+		}
+	case *object.String:
+		e.out.WriteString(QuoteJSON(obj.Value))
+	case *object.Array:
+		if err := e.enter(obj, at); err != nil {
+			return err
+		}
+
+		e.out.WriteByte('[')
+		for i, el := range obj.Elements {
+			if i > 0 {
+				e.out.WriteByte(',')
+			}
+			e.newline(depth + 1)
+			if err := e.encode(el, fmt.Sprintf("%s[%d]", at, i), depth+1); err != nil {
+				return err
+			}
+		}
+		if len(obj.Elements) > 0 {
+			e.newline(depth)
+		}
+		e.out.WriteByte(']')
+
+		e.path = e.path[:len(e.path)-1]
+	case *object.Hash:
+		if err := e.enter(obj, at); err != nil {
+			return err
+		}
+
+		type member struct {
+			key   string
+			value object.Object
+		}
+
+		members := []member{}
+		for _, pair := range obj.Ordered() {
+			switch key := pair.Key.(type) {
+			case *object.String:
+				members = append(members, member{key.Value, pair.Value})
+			case *object.Integer, *object.Float, *object.Boolean, *object.Null:
+				members = append(members, member{key.Inspect(), pair.Value})
+			default:
+				return NewError("cannot use %s as JSON object key at %s", pair.Key.Type(), at)
+			}
+		}
+		if e.sortKeys {
+			sort.SliceStable(members, func(i, j int) bool { return members[i].key < members[j].key })
+		}
+
+		e.out.WriteByte('{')
+		for i, m := range members {
+			if i > 0 {
+				e.out.WriteByte(',')
+			}
+			e.newline(depth + 1)
+			e.out.WriteString(QuoteJSON(m.key))
+			e.out.WriteByte(':')
+			if e.indent != "" {
+				e.out.WriteByte(' ')
+			}
+			if err := e.encode(m.value, at+"."+m.key, depth+1); err != nil {
+				return err
+			}
+		}
+		if len(members) > 0 {
+			e.newline(depth)
+		}
+		e.out.WriteByte('}')
+
+		e.path = e.path[:len(e.path)-1]
+	default:
+		return NewError("cannot serialize %s to JSON at %s", obj.Type(), at)
+	}
+
+	return nil
+}
+
+// QuoteJSON returns s as a JSON string literal, leaving HTML characters
+// unescaped.
+func QuoteJSON(s string) string {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
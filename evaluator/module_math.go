@@ -0,0 +1,587 @@
+package evaluator
+
+import (
+	"doge/object"
+	"math"
+	"math/big"
+)
+
+func NewMathModule() *object.Module {
+	members := map[string]object.Object{
+		"pi":      &object.Float{Value: math.Pi},
+		"e":       &object.Float{Value: math.E},
+		"tau":     &object.Float{Value: 2 * math.Pi},
+		"inf":     &object.Float{Value: math.Inf(1)},
+		"nan":     &object.Float{Value: math.NaN()},
+		"max_int": &object.Integer{Value: math.MaxInt64},
+		"min_int": &object.Integer{Value: math.MinInt64},
+
+		"sqrt":    floatFunction("sqrt", math.Sqrt, "This function returns the square root of a number!"),
+		"cbrt":    floatFunction("cbrt", math.Cbrt, "This function returns the cube root of a number!"),
+		"exp":     floatFunction("exp", math.Exp, "This function returns e to the power of a number!"),
+		"sin":     floatFunction("sin", math.Sin, "This function returns the sine of an angle in radians!"),
+		"cos":     floatFunction("cos", math.Cos, "This function returns the cosine of an angle in radians!"),
+		"tan":     floatFunction("tan", math.Tan, "This function returns the tangent of an angle in radians!"),
+		"asin":    floatFunction("asin", math.Asin, "This function returns the arcsine of a number in radians!"),
+		"acos":    floatFunction("acos", math.Acos, "This function returns the arccosine of a number in radians!"),
+		"sinh":    floatFunction("sinh", math.Sinh, "This function returns the hyperbolic sine of a number!"),
+		"cosh":    floatFunction("cosh", math.Cosh, "This function returns the hyperbolic cosine of a number!"),
+		"tanh":    floatFunction("tanh", math.Tanh, "This function returns the hyperbolic tangent of a number!"),
+		"degrees": floatFunction("degrees", func(x float64) float64 { return x * 180 / math.Pi }, "This function converts radians to degrees!"),
+		"radians": floatFunction("radians", func(x float64) float64 { return x * math.Pi / 180 }, "This function converts degrees to radians!"),
+
+		"log": &object.Builtin{
+			Fn:            mathLog,
+			Documentation: "This function returns the natural logarithm of a number, or the logarithm to a given base!",
+		},
+		"atan": &object.Builtin{
+			Fn:            mathAtan,
+			Documentation: "This function returns the arctangent of a number, or of y/x using the signs of both if given two!",
+		},
+		"hypot": &object.Builtin{
+			Fn:            mathHypot,
+			Documentation: "This function returns the length of the hypotenuse sqrt(a*a + b*b)!",
+		},
+		"is_nan": &object.Builtin{
+			Fn:            floatPredicate("is_nan", math.IsNaN),
+			Documentation: "This function checks if a number is NaN!",
+		},
+		"is_inf": &object.Builtin{
+			Fn:            floatPredicate("is_inf", func(x float64) bool { return math.IsInf(x, 0) }),
+			Documentation: "This function checks if a number is infinite!",
+		},
+
+		"abs": &object.Builtin{
+			Fn:            mathAbs,
+			Documentation: "This function returns the absolute value of a number, keeping its type!",
+		},
+		"floor": &object.Builtin{
+			Fn:            roundingFunction("floor", math.Floor),
+			Documentation: "This function rounds a number down to an INTEGER!",
+		},
+		"ceil": &object.Builtin{
+			Fn:            roundingFunction("ceil", math.Ceil),
+			Documentation: "This function rounds a number up to an INTEGER!",
+		},
+		"round": &object.Builtin{
+			Fn:            mathRound,
+			Documentation: "This function rounds a number to the nearest INTEGER, halves away from zero, or to a FLOAT with the given number of decimals!",
+		},
+
+		"gcd": &object.Builtin{
+			Fn:            mathGcd,
+			Documentation: "This function returns the greatest common divisor of integers!",
+		},
+		"lcm": &object.Builtin{
+			Fn:            mathLcm,
+			Documentation: "This function returns the least common multiple of integers!",
+		},
+		"isqrt": &object.Builtin{
+			Fn:            mathIsqrt,
+			Documentation: "This function returns the integer square root of a non-negative integer, rounded down!",
+		},
+		"powmod": &object.Builtin{
+			Fn:            mathPowmod,
+			Documentation: "This function returns base ** exp % mod without overflowing. Usage: powmod(base, exp, mod)",
+		},
+		"is_prime": &object.Builtin{
+			Fn:            mathIsPrime,
+			Documentation: "This function checks if an integer is prime!",
+		},
+		"factorize": &object.Builtin{
+			Fn:            mathFactorize,
+			Documentation: "This function returns the prime factors of a positive integer in ascending order, repeated by multiplicity!",
+		},
+		"divmod": &object.Builtin{
+			Fn:            mathDivmod,
+			Documentation: "This function returns [a / b, a % b] rounded towards negative infinity, so the remainder has the sign of b!",
+		},
+		"factorial": &object.Builtin{
+			Fn:            mathFactorial,
+			Documentation: "This function returns the factorial of a non-negative integer!",
+		},
+		"comb": &object.Builtin{
+			Fn:            combinatoricFunction("comb", true),
+			Documentation: "This function returns the number of ways to choose k of n items without order. Usage: comb(n, k)",
+		},
+		"perm": &object.Builtin{
+			Fn:            combinatoricFunction("perm", false),
+			Documentation: "This function returns the number of ways to choose k of n items in order. Usage: perm(n, k)",
+		},
+	}
+
+	return &object.Module{Name: "math", Members: members}
+}
+
+func NumberArgument(name string, args []object.Object, idx int) (float64, *object.Error) {
+	if !IsNumeric(args[idx]) {
+		return 0, ArgumentTypeError(name, idx, "INTEGER or FLOAT", args[idx])
+	}
+
+	return ObjectToFloat(args[idx]), nil
+}
+
+func floatFunction(name string, fn func(float64) float64, doc string) *object.Builtin {
+	name = "math." + name
+
+	return &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := CheckArgumentCount(args, 1, 1); err != nil {
+				return err
+			}
+			x, err := NumberArgument(name, args, 0)
+			if err != nil {
+				return err
+			}
+
+			return &object.Float{Value: fn(x)}
+		},
+		Documentation: doc,
+	}
+}
+
+func floatPredicate(name string, fn func(float64) bool) object.BuiltinFunction {
+	name = "math." + name
+
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 1, 1); err != nil {
+			return err
+		}
+		x, err := NumberArgument(name, args, 0)
+		if err != nil {
+			return err
+		}
+
+		return NativeBoolToBooleanObject(fn(x))
+	}
+}
+
+func mathLog(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	x, err := NumberArgument("math.log", args, 0)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return &object.Float{Value: math.Log(x)}
+	}
+
+	base, err := NumberArgument("math.log", args, 1)
+	if err != nil {
+		return err
+	}
+
+	switch base {
+	case 2:
+		return &object.Float{Value: math.Log2(x)}
+	case 10:
+		return &object.Float{Value: math.Log10(x)}
+	default:
+		return &object.Float{Value: math.Log(x) / math.Log(base)}
+	}
+}
+
+func mathAtan(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	y, err := NumberArgument("math.atan", args, 0)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return &object.Float{Value: math.Atan(y)}
+	}
+
+	x, err := NumberArgument("math.atan", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return &object.Float{Value: math.Atan2(y, x)}
+}
+
+func mathHypot(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	a, err := NumberArgument("math.hypot", args, 0)
+	if err != nil {
+		return err
+	}
+	b, err := NumberArgument("math.hypot", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return &object.Float{Value: math.Hypot(a, b)}
+}
+
+func mathAbs(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		if arg.Value == math.MinInt64 {
+			return NewError("integer overflow in `math.abs`")
+		}
+		if arg.Value < 0 {
+			return &object.Integer{Value: -arg.Value}
+		}
+		return arg
+	case *object.Float:
+		return &object.Float{Value: math.Abs(arg.Value)}
+	default:
+		return ArgumentTypeError("math.abs", 0, "INTEGER or FLOAT", arg)
+	}
+}
+
+// FloatToInteger converts a whole float to an INTEGER, failing for values
+// that don't fit, such as NaN and infinities.
+func FloatToInteger(name string, x float64) object.Object {
+	if math.IsNaN(x) || x < math.MinInt64 || x >= math.MaxInt64 {
+		return NewError("cannot convert %g to INTEGER in `%s`", x, name)
+	}
+
+	return &object.Integer{Value: int64(x)}
+}
+
+func roundingFunction(name string, fn func(float64) float64) object.BuiltinFunction {
+	name = "math." + name
+
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 1, 1); err != nil {
+			return err
+		}
+		if integer, ok := args[0].(*object.Integer); ok {
+			return integer
+		}
+		x, err := NumberArgument(name, args, 0)
+		if err != nil {
+			return err
+		}
+
+		return FloatToInteger(name, fn(x))
+	}
+}
+
+func mathRound(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return roundingFunction("round", math.Round)(env, args...)
+	}
+
+	x, err := NumberArgument("math.round", args, 0)
+	if err != nil {
+		return err
+	}
+	digits, err := IntegerArgument("math.round", args, 1)
+	if err != nil {
+		return err
+	}
+
+	scale := math.Pow(10, float64(digits))
+
+	return &object.Float{Value: math.Round(x*scale) / scale}
+}
+
+// integerArguments checks that all arguments are INTEGERs.
+func integerArguments(name string, args []object.Object) ([]int64, *object.Error) {
+	values := make([]int64, len(args))
+
+	for i, arg := range args {
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return nil, NewError("arguments to `%s` must be INTEGER, got %s", name, arg.Type())
+		}
+		values[i] = integer.Value
+	}
+
+	return values, nil
+}
+
+// BigToInteger converts the result of a big.Int computation back to an
+// INTEGER, failing if it overflowed.
+func BigToInteger(name string, n *big.Int) object.Object {
+	if !n.IsInt64() {
+		return NewError("integer overflow in `%s`", name)
+	}
+
+	return &object.Integer{Value: n.Int64()}
+}
+
+func mathGcd(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, -1); err != nil {
+		return err
+	}
+	values, err := integerArguments("math.gcd", args)
+	if err != nil {
+		return err
+	}
+
+	result := new(big.Int)
+	for _, v := range values {
+		result.GCD(nil, nil, result, new(big.Int).Abs(big.NewInt(v)))
+	}
+
+	return BigToInteger("math.gcd", result)
+}
+
+func mathLcm(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, -1); err != nil {
+		return err
+	}
+	values, err := integerArguments("math.lcm", args)
+	if err != nil {
+		return err
+	}
+
+	result := big.NewInt(1)
+	for _, v := range values {
+		n := new(big.Int).Abs(big.NewInt(v))
+		if n.Sign() == 0 {
+			return &object.Integer{Value: 0}
+		}
+
+		gcd := new(big.Int).GCD(nil, nil, result, n)
+		result.Mul(result, n.Quo(n, gcd))
+	}
+
+	return BigToInteger("math.lcm", result)
+}
+
+func mathIsqrt(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	n, err := IntegerArgument("math.isqrt", args, 0)
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return NewError("argument to `math.isqrt` must not be negative, got %d", n)
+	}
+
+	return &object.Integer{Value: new(big.Int).Sqrt(big.NewInt(n)).Int64()}
+}
+
+func mathPowmod(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 3, 3); err != nil {
+		return err
+	}
+	values, err := integerArguments("math.powmod", args)
+	if err != nil {
+		return err
+	}
+	if values[1] < 0 {
+		return NewError("exponent for `math.powmod` must not be negative, got %d", values[1])
+	}
+	if values[2] <= 0 {
+		return NewError("modulus for `math.powmod` must be positive, got %d", values[2])
+	}
+
+	base := new(big.Int).Mod(big.NewInt(values[0]), big.NewInt(values[2]))
+	result := base.Exp(base, big.NewInt(values[1]), big.NewInt(values[2]))
+
+	return &object.Integer{Value: result.Int64()}
+}
+
+func mathIsPrime(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	n, err := IntegerArgument("math.is_prime", args, 0)
+	if err != nil {
+		return err
+	}
+
+	// ProbablyPrime(0) is exact for all 64 bit integers
+	return NativeBoolToBooleanObject(n > 1 && big.NewInt(n).ProbablyPrime(0))
+}
+
+func mathFactorize(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	n, err := IntegerArgument("math.factorize", args, 0)
+	if err != nil {
+		return err
+	}
+	if n < 1 {
+		return NewError("argument to `math.factorize` must be positive, got %d", n)
+	}
+
+	factors := []object.Object{}
+	for p := int64(2); p <= n/p; p++ {
+		for n%p == 0 {
+			factors = append(factors, &object.Integer{Value: p})
+			n /= p
+		}
+	}
+	if n > 1 {
+		factors = append(factors, &object.Integer{Value: n})
+	}
+
+	return &object.Array{Elements: factors}
+}
+
+func mathDivmod(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	values, err := integerArguments("math.divmod", args)
+	if err != nil {
+		return err
+	}
+
+	a, b := values[0], values[1]
+	if b == 0 {
+		return NewError("division by zero in `math.divmod`")
+	}
+
+	q, r := a/b, a%b
+	if r != 0 && (r < 0) != (b < 0) {
+		q, r = q-1, r+b
+	}
+
+	return &object.Array{Elements: []object.Object{&object.Integer{Value: q}, &object.Integer{Value: r}}}
+}
+
+func mathFactorial(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	n, err := IntegerArgument("math.factorial", args, 0)
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return NewError("argument to `math.factorial` must not be negative, got %d", n)
+	}
+	if n > 20 {
+		return NewError("integer overflow in `math.factorial`")
+	}
+
+	return BigToInteger("math.factorial", new(big.Int).MulRange(1, n))
+}
+
+func combinatoricFunction(name string, unordered bool) object.BuiltinFunction {
+	name = "math." + name
+
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if err := CheckArgumentCount(args, 2, 2); err != nil {
+			return err
+		}
+		values, err := integerArguments(name, args)
+		if err != nil {
+			return err
+		}
+
+		n, k := values[0], values[1]
+		if n < 0 || k < 0 {
+			return NewError("arguments to `%s` must not be negative", name)
+		}
+		if k > n {
+			return &object.Integer{Value: 0}
+		}
+
+		var result int64
+		var ok bool
+		if unordered {
+			result, ok = binomial(n, k)
+		} else {
+			result, ok = fallingFactorial(n, k)
+		}
+		if !ok {
+			return NewError("integer overflow in `%s`", name)
+		}
+
+		return &object.Integer{Value: result}
+	}
+}
+
+// binomial computes n choose k for 0 <= k <= n. Every step is itself a
+// binomial coefficient, and the step is reduced before multiplying, so ok is
+// only false if the result doesn't fit in an int64. It stops at the first
+// overflow, however large n is.
+func binomial(n, k int64) (result int64, ok bool) {
+	if k > n-k {
+		k = n - k
+	}
+
+	result = 1
+	for i := int64(1); i <= k; i++ {
+		// result * (n-k+i) is divisible by i
+		g := gcdInts(result, i)
+		result /= g
+		if result, ok = multiplyInts(result, (n-k+i)/(i/g)); !ok {
+			return 0, false
+		}
+	}
+
+	return result, true
+}
+
+// fallingFactorial computes n * (n-1) * ... * (n-k+1) for 0 <= k <= n,
+// stopping at the first overflow.
+func fallingFactorial(n, k int64) (result int64, ok bool) {
+	result = 1
+	for i := n - k + 1; i <= n; i++ {
+		if result, ok = multiplyInts(result, i); !ok {
+			return 0, false
+		}
+	}
+
+	return result, true
+}
+
+func gcdInts(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+// IntPow computes base ** exp for a non-negative exp by squaring, so large
+// results stay exact instead of going through float64. ok is false if the
+// result doesn't fit in an int64.
+func IntPow(base, exp int64) (result int64, ok bool) {
+	result = 1
+
+	for exp > 0 {
+		if exp&1 == 1 {
+			if result, ok = multiplyInts(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		// squaring once more than needed could overflow on its own
+		if exp > 0 {
+			if base, ok = multiplyInts(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+
+	return result, true
+}
+
+func multiplyInts(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+
+	return product, true
+}
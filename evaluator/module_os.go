@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"doge/object"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+func NewOsModule() *object.Module {
+	members := map[string]object.Object{
+		"argv": &object.Builtin{
+			Fn:            osArgv,
+			Documentation: "This function returns the script path followed by the command line arguments!",
+		},
+		"getenv": &object.Builtin{
+			Fn:            osGetenv,
+			Documentation: "This function returns an environment variable, or a default (null) if it is not set. Usage: getenv(name, default)",
+		},
+		"setenv": &object.Builtin{
+			Fn:            osSetenv,
+			Documentation: "This function sets an environment variable!",
+		},
+		"environ": &object.Builtin{
+			Fn:            osEnviron,
+			Documentation: "This function returns all environment variables as a hash sorted by name!",
+		},
+		"exit": &object.Builtin{
+			Fn:            osExit,
+			Documentation: "This function ends the program with an exit code, 0 by default!",
+		},
+	}
+
+	return &object.Module{Name: "os", Members: members}
+}
+
+func osArgv(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 0); err != nil {
+		return err
+	}
+
+	return StringsToArray(env.Context().Args)
+}
+
+func osGetenv(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	name, err := StringArgument("os.getenv", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if value, ok := os.LookupEnv(name); ok {
+		return &object.String{Value: value}
+	}
+	if len(args) == 2 {
+		return args[1]
+	}
+
+	return NULL
+}
+
+func osSetenv(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	name, err := StringArgument("os.setenv", args, 0)
+	if err != nil {
+		return err
+	}
+	value, err := StringArgument("os.setenv", args, 1)
+	if err != nil {
+		return err
+	}
+
+	if setErr := os.Setenv(name, value); setErr != nil {
+		return NewError("`os.setenv` failed: %s", setErr)
+	}
+
+	return NULL
+}
+
+func osEnviron(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 0); err != nil {
+		return err
+	}
+
+	vars := os.Environ()
+	sort.Strings(vars)
+
+	environ := object.NewHash()
+	for _, v := range vars {
+		if idx := strings.Index(v, "="); idx > 0 {
+			environ.Set(&object.String{Value: v[:idx]}, &object.String{Value: v[idx+1:]})
+		}
+	}
+
+	return environ
+}
+
+func osExit(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 1); err != nil {
+		return err
+	}
+
+	code := int64(0)
+	if len(args) == 1 {
+		var err *object.Error
+		if code, err = IntegerArgument("os.exit", args, 0); err != nil {
+			return err
+		}
+	}
+
+	return &object.Error{Message: fmt.Sprintf("exit status %d", code), Exit: true, Code: int(code)}
+}
@@ -0,0 +1,256 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"doge/object"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Commands are run directly, not through a shell, so arguments need no
+// quoting. The options hash may contain cwd, env (a hash added to the current
+// environment), stdin (a string) and timeout (seconds or a duration).
+//
+// A non-zero exit code is not an error for `run` and `stream`, which return
+// it, but failing to start a command or running into the timeout is.
+func NewProcessModule() *object.Module {
+	members := map[string]object.Object{
+		"run": &object.Builtin{
+			Fn:            processRun,
+			Documentation: "This function runs a command and returns a hash with its stdout, stderr and exit code. Usage: run(cmd, args, {\"cwd\": dir, \"env\": vars, \"stdin\": str, \"timeout\": seconds})",
+		},
+		"stream": &object.Builtin{
+			Fn:            processStream,
+			Documentation: "This function runs a command with its output going straight to ours, and returns its exit code!",
+		},
+		"lines": &object.Builtin{
+			Fn:            processLines,
+			Documentation: "This function runs a command and returns an iterator over the lines of its stdout as they are written!",
+		},
+	}
+
+	return &object.Module{Name: "process", Members: members}
+}
+
+// waitDelay is how long a killed command may keep its output open, through
+// children that weren't killed with it, before we stop reading it.
+const waitDelay = time.Second
+
+// command is a prepared process with the context enforcing its timeout.
+type command struct {
+	name    string
+	cmd     *exec.Cmd
+	ctx     context.Context
+	cancel  context.CancelFunc
+	dir     string
+	env     []string
+	stdin   *strings.Reader
+	timeout time.Duration
+}
+
+func newCommand(name string, args []object.Object) (*command, *object.Error) {
+	if err := CheckArgumentCount(args, 1, 3); err != nil {
+		return nil, err
+	}
+	program, err := StringArgument(name, args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	cmdArgs := []string{}
+	if len(args) > 1 {
+		arr, err := ArrayArgument(name, args, 1)
+		if err != nil {
+			return nil, err
+		}
+		for _, el := range arr.Elements {
+			str, ok := el.(*object.String)
+			if !ok {
+				return nil, NewError("arguments for `%s` must be STRING, got %s", name, el.Type())
+			}
+			cmdArgs = append(cmdArgs, str.Value)
+		}
+	}
+
+	c := &command{name: name}
+	if len(args) > 2 {
+		options, err := HashArgument(name, args, 2)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.configure(options); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.timeout > 0 {
+		c.ctx, c.cancel = context.WithTimeout(context.Background(), c.timeout)
+	} else {
+		c.ctx, c.cancel = context.WithCancel(context.Background())
+	}
+	c.cmd = exec.CommandContext(c.ctx, program, cmdArgs...)
+	setProcessGroup(c.cmd)
+	c.cmd.WaitDelay = waitDelay
+	c.cmd.Dir = c.dir
+	c.cmd.Env = c.env
+	if c.stdin != nil {
+		c.cmd.Stdin = c.stdin
+	}
+
+	return c, nil
+}
+
+func (c *command) configure(options *object.Hash) *object.Error {
+	for _, pair := range options.Ordered() {
+		key, _ := pair.Key.(*object.String)
+		if key == nil {
+			return NewError("unknown option for `%s`: %s", c.name, pair.Key.Inspect())
+		}
+
+		switch value := pair.Value.(type) {
+		case *object.String:
+			switch key.Value {
+			case "cwd":
+				c.dir = value.Value
+			case "stdin":
+				c.stdin = strings.NewReader(value.Value)
+			default:
+				return NewError("invalid value for `%s` option %s: %s", c.name, key.Value, value.Inspect())
+			}
+		case *object.Hash:
+			if key.Value != "env" {
+				return NewError("invalid value for `%s` option %s: %s", c.name, key.Value, value.Inspect())
+			}
+			c.env = os.Environ()
+			for _, variable := range value.Ordered() {
+				for _, part := range []object.Object{variable.Key, variable.Value} {
+					if part.Type() != object.STRING_OBJ {
+						return NewError("environment variables for `%s` must be STRING, got %s", c.name, part.Type())
+					}
+				}
+				c.env = append(c.env, variable.Key.Inspect()+"="+variable.Value.Inspect())
+			}
+		case *object.Integer, *object.Float, *object.Duration:
+			if key.Value != "timeout" {
+				return NewError("invalid value for `%s` option %s: %s", c.name, key.Value, value.Inspect())
+			}
+			timeout, err := DurationArgument(c.name, []object.Object{value}, 0)
+			if err != nil {
+				return err
+			}
+			c.timeout = timeout
+		default:
+			return NewError("invalid value for `%s` option %s: %s", c.name, key.Value, value.Inspect())
+		}
+	}
+
+	return nil
+}
+
+// wait waits for the command and returns its exit code, or an error if it
+// didn't run to completion.
+func (c *command) wait(runErr error) (int64, *object.Error) {
+	defer c.cancel()
+
+	if c.ctx.Err() == context.DeadlineExceeded {
+		return 0, NewError("`%s` timed out after %s", c.name, c.timeout)
+	}
+	if exitErr, ok := runErr.(*exec.ExitError); ok {
+		return int64(exitErr.ExitCode()), nil
+	}
+	if runErr != nil {
+		return 0, FsError(c.name, runErr)
+	}
+
+	return 0, nil
+}
+
+func processRun(env *object.Environment, args ...object.Object) object.Object {
+	c, err := newCommand("process.run", args)
+	if err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	c.cmd.Stdout = &stdout
+	c.cmd.Stderr = &stderr
+
+	code, err := c.wait(c.cmd.Run())
+	if err != nil {
+		return err
+	}
+
+	result := object.NewHash()
+	result.Set(&object.String{Value: "stdout"}, &object.String{Value: stdout.String()})
+	result.Set(&object.String{Value: "stderr"}, &object.String{Value: stderr.String()})
+	result.Set(&object.String{Value: "code"}, &object.Integer{Value: code})
+
+	return result
+}
+
+func processStream(env *object.Environment, args ...object.Object) object.Object {
+	c, err := newCommand("process.stream", args)
+	if err != nil {
+		return err
+	}
+
+	c.cmd.Stdout = env.Context().Stdout
+	c.cmd.Stderr = env.Context().Stderr
+
+	code, err := c.wait(c.cmd.Run())
+	if err != nil {
+		return err
+	}
+
+	return &object.Integer{Value: code}
+}
+
+// processLines runs the command while its output is iterated. The stderr of
+// the command goes to ours, and a non-zero exit code ends the iteration with
+// an error. Stopping early kills the command.
+func processLines(env *object.Environment, args ...object.Object) object.Object {
+	c, err := newCommand("process.lines", args)
+	if err != nil {
+		return err
+	}
+
+	c.cmd.Stderr = env.Context().Stderr
+	stdout, pipeErr := c.cmd.StdoutPipe()
+	if pipeErr != nil {
+		c.cancel()
+		return FsError(c.name, pipeErr)
+	}
+	if startErr := c.cmd.Start(); startErr != nil {
+		c.cancel()
+		return FsError(c.name, startErr)
+	}
+
+	var waitErr *object.Error
+	it := LineIterator(c.name, stdout, func() {
+		code, err := c.wait(c.cmd.Wait())
+		if err == nil && code != 0 {
+			err = NewError("`%s` failed: exit status %d", c.name, code)
+		}
+		waitErr = err
+	})
+
+	next, close := it.Next, it.Close
+	it.Next = func() (object.Object, bool) {
+		line, ok := next()
+		if !ok && waitErr != nil {
+			err := waitErr
+			waitErr = nil
+			return err, true
+		}
+		return line, ok
+	}
+	it.Close = func() {
+		c.cancel()
+		close()
+	}
+
+	return it
+}
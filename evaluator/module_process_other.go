@@ -0,0 +1,9 @@
+//go:build !unix
+
+package evaluator
+
+import "os/exec"
+
+// setProcessGroup does nothing where there are no process groups. Only the
+// command itself is killed, and waitDelay stops waiting for its children.
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,174 @@
+package evaluator
+
+import (
+	"doge/object"
+	"math/rand"
+)
+
+// The random module draws from the generator in the interpreter context, so
+// seeding it only affects the interpreter that called `random.seed`.
+func NewRandomModule() *object.Module {
+	members := map[string]object.Object{
+		"seed": &object.Builtin{
+			Fn:            randomSeed,
+			Documentation: "This function seeds the random generator, making the following numbers reproducible!",
+		},
+		"int": &object.Builtin{
+			Fn:            randomInt,
+			Documentation: "This function returns a random integer between lo and hi, both included. Usage: int(lo, hi)",
+		},
+		"float": &object.Builtin{
+			Fn:            randomFloat,
+			Documentation: "This function returns a random float between 0 and 1, excluding 1!",
+		},
+		"choice": &object.Builtin{
+			Fn:            randomChoice,
+			Documentation: "This function returns a random element of an array!",
+		},
+		"shuffle": &object.Builtin{
+			Fn:            randomShuffle,
+			Documentation: "This function shuffles an array in place!",
+		},
+		"sample": &object.Builtin{
+			Fn:            randomSample,
+			Documentation: "This function returns k elements from distinct positions of an array. Usage: sample(arr, k)",
+		},
+		"gauss": &object.Builtin{
+			Fn:            randomGauss,
+			Documentation: "This function returns a normally distributed float. Usage: gauss(mu=0, sigma=1)",
+		},
+	}
+
+	return &object.Module{Name: "random", Members: members}
+}
+
+func randomSeed(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	seed, err := IntegerArgument("random.seed", args, 0)
+	if err != nil {
+		return err
+	}
+
+	env.Context().Random = rand.New(rand.NewSource(seed))
+
+	return NULL
+}
+
+func randomInt(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	values, err := integerArguments("random.int", args)
+	if err != nil {
+		return err
+	}
+
+	lo, hi := values[0], values[1]
+	if lo > hi {
+		return NewError("empty range for `random.int`: %d > %d", lo, hi)
+	}
+
+	span := uint64(hi - lo)
+	if span == 1<<64-1 {
+		return &object.Integer{Value: int64(env.Context().Random.Uint64())}
+	}
+
+	// draw from [0, span] without the bias of a plain modulo
+	limit := (1<<64 - 1) - (1<<64-1)%(span+1)
+	for {
+		n := env.Context().Random.Uint64()
+		if n < limit {
+			return &object.Integer{Value: lo + int64(n%(span+1))}
+		}
+	}
+}
+
+func randomFloat(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 0); err != nil {
+		return err
+	}
+
+	return &object.Float{Value: env.Context().Random.Float64()}
+}
+
+func randomChoice(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("random.choice", args, 0)
+	if err != nil {
+		return err
+	}
+	if len(arr.Elements) == 0 {
+		return NewError("cannot choose from an empty array")
+	}
+
+	return arr.Elements[env.Context().Random.Intn(len(arr.Elements))]
+}
+
+func randomShuffle(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("random.shuffle", args, 0)
+	if err != nil {
+		return err
+	}
+	if arr.Frozen {
+		return NewError("cannot modify frozen array")
+	}
+
+	env.Context().Random.Shuffle(len(arr.Elements), func(i, j int) {
+		arr.Elements[i], arr.Elements[j] = arr.Elements[j], arr.Elements[i]
+	})
+
+	return NULL
+}
+
+func randomSample(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	arr, err := ArrayArgument("random.sample", args, 0)
+	if err != nil {
+		return err
+	}
+	k, err := IntegerArgument("random.sample", args, 1)
+	if err != nil {
+		return err
+	}
+	if k < 0 || k > int64(len(arr.Elements)) {
+		return NewError("sample size for `random.sample` must be between 0 and %d, got %d", len(arr.Elements), k)
+	}
+
+	perm := env.Context().Random.Perm(len(arr.Elements))
+	sample := make([]object.Object, k)
+	for i := range sample {
+		sample[i] = arr.Elements[perm[i]]
+	}
+
+	return &object.Array{Elements: sample}
+}
+
+func randomGauss(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 2); err != nil {
+		return err
+	}
+
+	mu, sigma := 0.0, 1.0
+	var err *object.Error
+	if len(args) > 0 {
+		if mu, err = NumberArgument("random.gauss", args, 0); err != nil {
+			return err
+		}
+	}
+	if len(args) > 1 {
+		if sigma, err = NumberArgument("random.gauss", args, 1); err != nil {
+			return err
+		}
+	}
+
+	return &object.Float{Value: mu + sigma*env.Context().Random.NormFloat64()}
+}
@@ -0,0 +1,232 @@
+package evaluator
+
+import (
+	"doge/object"
+	"regexp"
+	"strings"
+)
+
+// The re module uses Go's RE2 syntax. Doge strings don't process escapes, so
+// patterns like "\d+" can be written as they are.
+//
+// Every function takes either a pattern string or a compiled pattern. Matches
+// are hashes with the keys match, start, end, groups and named, where named
+// maps the names of `(?P<name>...)` groups to what they matched.
+func NewReModule() *object.Module {
+	members := map[string]object.Object{
+		"compile": &object.Builtin{
+			Fn:            reCompile,
+			Documentation: "This function compiles a regular expression for repeated use!",
+		},
+		"match": &object.Builtin{
+			Fn:            reMatch("re.match", true),
+			Documentation: "This function matches a pattern at the start of a string, returning a match hash or null!",
+		},
+		"search": &object.Builtin{
+			Fn:            reMatch("re.search", false),
+			Documentation: "This function finds the first match of a pattern in a string, returning a match hash or null!",
+		},
+		"find_all": &object.Builtin{
+			Fn:            reFindAll,
+			Documentation: "This function returns all matches of a pattern, as strings, or the groups of each match if the pattern has groups!",
+		},
+		"replace": &object.Builtin{
+			Fn:            reReplace,
+			Documentation: "This function replaces matches with a string using $1 or ${name}, or with the result of a function called with each match hash. Usage: replace(pattern, str, repl, count)",
+		},
+		"split": &object.Builtin{
+			Fn:            reSplit,
+			Documentation: "This function splits a string at the matches of a pattern, into at most n parts if given!",
+		},
+	}
+
+	return &object.Module{Name: "re", Members: members}
+}
+
+func CompilePattern(name string, args []object.Object, idx int) (*regexp.Regexp, *object.Error) {
+	switch arg := args[idx].(type) {
+	case *object.Pattern:
+		return arg.Regexp, nil
+	case *object.String:
+		re, err := regexp.Compile(arg.Value)
+		if err != nil {
+			return nil, NewError("invalid regular expression in `%s`: %s", name, err)
+		}
+		return re, nil
+	default:
+		return nil, ArgumentTypeError(name, idx, "STRING or PATTERN", arg)
+	}
+}
+
+// patternAndString checks the pattern and subject arguments shared by every
+// function of the module.
+func patternAndString(name string, args []object.Object, min, max int) (*regexp.Regexp, string, *object.Error) {
+	if err := CheckArgumentCount(args, min, max); err != nil {
+		return nil, "", err
+	}
+	re, err := CompilePattern(name, args, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	s, err := StringArgument(name, args, 1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return re, s, nil
+}
+
+func reCompile(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	re, err := CompilePattern("re.compile", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return &object.Pattern{Regexp: re}
+}
+
+// groupValue returns the text of a submatch, or null for a group that didn't
+// take part in the match.
+func groupValue(s string, loc []int, i int) object.Object {
+	if loc[2*i] < 0 {
+		return NULL
+	}
+
+	return &object.String{Value: s[loc[2*i]:loc[2*i+1]]}
+}
+
+// MatchObject builds the match hash for the submatch indices loc in s.
+func MatchObject(re *regexp.Regexp, s string, loc []int) *object.Hash {
+	groups := &object.Array{Elements: []object.Object{}}
+	named := object.NewHash()
+
+	for i, name := range re.SubexpNames() {
+		if i == 0 {
+			continue
+		}
+		groups.Elements = append(groups.Elements, groupValue(s, loc, i))
+		if name != "" {
+			named.Set(&object.String{Value: name}, groupValue(s, loc, i))
+		}
+	}
+
+	match := object.NewHash()
+	match.Set(&object.String{Value: "match"}, groupValue(s, loc, 0))
+	match.Set(&object.String{Value: "start"}, &object.Integer{Value: int64(loc[0])})
+	match.Set(&object.String{Value: "end"}, &object.Integer{Value: int64(loc[1])})
+	match.Set(&object.String{Value: "groups"}, groups)
+	match.Set(&object.String{Value: "named"}, named)
+
+	return match
+}
+
+func reMatch(name string, anchored bool) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		re, s, err := patternAndString(name, args, 2, 2)
+		if err != nil {
+			return err
+		}
+
+		loc := re.FindStringSubmatchIndex(s)
+		// a match at the start would be the leftmost one
+		if loc == nil || (anchored && loc[0] != 0) {
+			return NULL
+		}
+
+		return MatchObject(re, s, loc)
+	}
+}
+
+func reFindAll(env *object.Environment, args ...object.Object) object.Object {
+	re, s, err := patternAndString("re.find_all", args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	results := []object.Object{}
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		switch re.NumSubexp() {
+		case 0:
+			results = append(results, groupValue(s, loc, 0))
+		case 1:
+			results = append(results, groupValue(s, loc, 1))
+		default:
+			groups := make([]object.Object, re.NumSubexp())
+			for i := range groups {
+				groups[i] = groupValue(s, loc, i+1)
+			}
+			results = append(results, &object.Array{Elements: groups})
+		}
+	}
+
+	return &object.Array{Elements: results}
+}
+
+func reReplace(env *object.Environment, args ...object.Object) object.Object {
+	re, s, err := patternAndString("re.replace", args, 3, 4)
+	if err != nil {
+		return err
+	}
+
+	count := int64(-1)
+	if len(args) == 4 {
+		if count, err = IntegerArgument("re.replace", args, 3); err != nil {
+			return err
+		}
+	}
+
+	var template string
+	var fn object.Object
+	switch repl := args[2].(type) {
+	case *object.String:
+		template = repl.Value
+	case *object.Function, *object.Builtin:
+		fn = repl
+	default:
+		return ArgumentTypeError("re.replace", 2, "STRING or FUNCTION", repl)
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(s, int(count)) {
+		out.WriteString(s[last:loc[0]])
+		last = loc[1]
+
+		if fn == nil {
+			out.Write(re.ExpandString(nil, template, s, loc))
+			continue
+		}
+
+		result := ApplyFunction(fn, []object.Object{MatchObject(re, s, loc)}, env)
+		if IsError(result) {
+			return result
+		}
+		str, ok := result.(*object.String)
+		if !ok {
+			return NewError("replacement function for `re.replace` must return STRING, got %s", result.Type())
+		}
+		out.WriteString(str.Value)
+	}
+	out.WriteString(s[last:])
+
+	return &object.String{Value: out.String()}
+}
+
+func reSplit(env *object.Environment, args ...object.Object) object.Object {
+	re, s, err := patternAndString("re.split", args, 2, 3)
+	if err != nil {
+		return err
+	}
+
+	n := int64(-1)
+	if len(args) == 3 {
+		if n, err = IntegerArgument("re.split", args, 2); err != nil {
+			return err
+		}
+	}
+
+	return StringsToArray(re.Split(s, int(n)))
+}
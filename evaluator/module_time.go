@@ -0,0 +1,333 @@
+package evaluator
+
+import (
+	"doge/object"
+	"time"
+	// embed the zone database, so time zones work on hosts without one
+	_ "time/tzdata"
+)
+
+// clockStart is the reference point of `time.clock`.
+var clockStart = time.Now()
+
+// Times and durations support arithmetic: `time.now() - start` is a duration,
+// `t + 2 * time.hour` is a time. Layouts are Go reference layouts like
+// "2006-01-02 15:04", and time zones are IANA names like "Europe/Berlin",
+// "UTC" or "Local".
+func NewTimeModule() *object.Module {
+	members := map[string]object.Object{
+		"now": &object.Builtin{
+			Fn:            timeNow,
+			Documentation: "This function returns the current time, in the local zone or the given one!",
+		},
+		"clock": &object.Builtin{
+			Fn:            timeClock,
+			Documentation: "This function returns the seconds of a monotonic clock as a float, for measuring elapsed time!",
+		},
+		"sleep": &object.Builtin{
+			Fn:            timeSleep,
+			Documentation: "This function pauses for a number of seconds or a duration!",
+		},
+		"format": &object.Builtin{
+			Fn:            timeFormat,
+			Documentation: "This function formats a time with a layout, RFC 3339 by default. Usage: format(t, \"2006-01-02 15:04\")",
+		},
+		"parse": &object.Builtin{
+			Fn:            timeParse,
+			Documentation: "This function parses a time with a layout, in UTC or the given zone. Usage: parse(str, layout, tz)",
+		},
+		"duration": &object.Builtin{
+			Fn:            timeDuration,
+			Documentation: "This function returns a duration from a string like \"1h30m\" or a number of seconds!",
+		},
+		"date": &object.Builtin{
+			Fn:            timeDate,
+			Documentation: "This function returns the time of a date, in the local zone or the given one. Usage: date(year, month, day, hour, minute, second, tz)",
+		},
+		"from_unix": &object.Builtin{
+			Fn:            timeFromUnix,
+			Documentation: "This function returns the time of a Unix timestamp in seconds, in the local zone or the given one!",
+		},
+		"in_zone": &object.Builtin{
+			Fn:            timeInZone,
+			Documentation: "This function returns the same time in another time zone!",
+		},
+		"nanosecond":  &object.Duration{Value: time.Nanosecond},
+		"microsecond": &object.Duration{Value: time.Microsecond},
+		"millisecond": &object.Duration{Value: time.Millisecond},
+		"second":      &object.Duration{Value: time.Second},
+		"minute":      &object.Duration{Value: time.Minute},
+		"hour":        &object.Duration{Value: time.Hour},
+		"rfc3339":     &object.String{Value: time.RFC3339},
+		"rfc1123":     &object.String{Value: time.RFC1123},
+		"date_only":   &object.String{Value: "2006-01-02"},
+		"time_only":   &object.String{Value: "15:04:05"},
+		"date_time":   &object.String{Value: "2006-01-02 15:04:05"},
+	}
+
+	return &object.Module{Name: "time", Members: members}
+}
+
+// TimeMember returns the named field of a time, or nil if there is none.
+func TimeMember(t time.Time, name string) object.Object {
+	switch name {
+	case "year":
+		return &object.Integer{Value: int64(t.Year())}
+	case "month":
+		return &object.Integer{Value: int64(t.Month())}
+	case "day":
+		return &object.Integer{Value: int64(t.Day())}
+	case "hour":
+		return &object.Integer{Value: int64(t.Hour())}
+	case "minute":
+		return &object.Integer{Value: int64(t.Minute())}
+	case "second":
+		return &object.Integer{Value: int64(t.Second())}
+	case "nanosecond":
+		return &object.Integer{Value: int64(t.Nanosecond())}
+	case "weekday":
+		return &object.String{Value: t.Weekday().String()}
+	case "yearday":
+		return &object.Integer{Value: int64(t.YearDay())}
+	case "zone":
+		zone, _ := t.Zone()
+		return &object.String{Value: zone}
+	case "unix":
+		return &object.Integer{Value: t.Unix()}
+	default:
+		return nil
+	}
+}
+
+// DurationMember returns the named field of a duration, or nil if there is
+// none.
+func DurationMember(d time.Duration, name string) object.Object {
+	switch name {
+	case "hours":
+		return &object.Float{Value: d.Hours()}
+	case "minutes":
+		return &object.Float{Value: d.Minutes()}
+	case "seconds":
+		return &object.Float{Value: d.Seconds()}
+	case "milliseconds":
+		return &object.Integer{Value: d.Milliseconds()}
+	case "nanoseconds":
+		return &object.Integer{Value: d.Nanoseconds()}
+	default:
+		return nil
+	}
+}
+
+func TimeArgument(name string, args []object.Object, idx int) (time.Time, *object.Error) {
+	t, ok := args[idx].(*object.Time)
+	if !ok {
+		return time.Time{}, ArgumentTypeError(name, idx, "TIME", args[idx])
+	}
+
+	return t.Value, nil
+}
+
+// DurationArgument accepts a duration or a number of seconds.
+func DurationArgument(name string, args []object.Object, idx int) (time.Duration, *object.Error) {
+	if d, ok := args[idx].(*object.Duration); ok {
+		return d.Value, nil
+	}
+	if !IsNumeric(args[idx]) {
+		return 0, ArgumentTypeError(name, idx, "DURATION, INTEGER or FLOAT", args[idx])
+	}
+
+	return time.Duration(ObjectToFloat(args[idx]) * float64(time.Second)), nil
+}
+
+// LocationArgument loads the time zone named by the argument at idx, or
+// returns def if there are fewer arguments.
+func LocationArgument(name string, args []object.Object, idx int, def *time.Location) (*time.Location, *object.Error) {
+	if len(args) <= idx {
+		return def, nil
+	}
+	tz, err := StringArgument(name, args, idx)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, loadErr := time.LoadLocation(tz)
+	if loadErr != nil {
+		return nil, NewError("unknown time zone in `%s`: %s", name, tz)
+	}
+
+	return loc, nil
+}
+
+func timeNow(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 1); err != nil {
+		return err
+	}
+	loc, err := LocationArgument("time.now", args, 0, time.Local)
+	if err != nil {
+		return err
+	}
+
+	return &object.Time{Value: time.Now().In(loc)}
+}
+
+func timeClock(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 0, 0); err != nil {
+		return err
+	}
+
+	return &object.Float{Value: time.Since(clockStart).Seconds()}
+}
+
+func timeSleep(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+	d, err := DurationArgument("time.sleep", args, 0)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(d)
+
+	return NULL
+}
+
+func timeFormat(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	t, err := TimeArgument("time.format", args, 0)
+	if err != nil {
+		return err
+	}
+
+	layout := time.RFC3339
+	if len(args) == 2 {
+		if layout, err = StringArgument("time.format", args, 1); err != nil {
+			return err
+		}
+	}
+
+	return &object.String{Value: t.Format(layout)}
+}
+
+func timeParse(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 3); err != nil {
+		return err
+	}
+	value, err := StringArgument("time.parse", args, 0)
+	if err != nil {
+		return err
+	}
+
+	layout := time.RFC3339
+	if len(args) > 1 {
+		if layout, err = StringArgument("time.parse", args, 1); err != nil {
+			return err
+		}
+	}
+	loc, err := LocationArgument("time.parse", args, 2, time.UTC)
+	if err != nil {
+		return err
+	}
+
+	t, parseErr := time.ParseInLocation(layout, value, loc)
+	if parseErr != nil {
+		return NewError("`time.parse` failed: %s", parseErr)
+	}
+
+	return &object.Time{Value: t}
+}
+
+func timeDuration(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	if str, ok := args[0].(*object.String); ok {
+		d, parseErr := time.ParseDuration(str.Value)
+		if parseErr != nil {
+			return NewError("`time.duration` failed: %s", parseErr)
+		}
+		return &object.Duration{Value: d}
+	}
+
+	d, err := DurationArgument("time.duration", args, 0)
+	if err != nil {
+		return ArgumentTypeError("time.duration", 0, "STRING, INTEGER or FLOAT", args[0])
+	}
+
+	return &object.Duration{Value: d}
+}
+
+func timeDate(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 3, 7); err != nil {
+		return err
+	}
+
+	// the zone may follow any of the optional time fields
+	fields := args
+	loc := time.Local
+	if _, ok := args[len(args)-1].(*object.String); ok && len(args) > 3 {
+		fields = args[:len(args)-1]
+		var err *object.Error
+		if loc, err = LocationArgument("time.date", args, len(args)-1, loc); err != nil {
+			return err
+		}
+	}
+	if len(fields) > 6 {
+		return ArgumentTypeError("time.date", 6, "STRING", args[6])
+	}
+
+	values := make([]int, 6)
+	for i := range fields {
+		n, err := IntegerArgument("time.date", fields, i)
+		if err != nil {
+			return err
+		}
+		values[i] = int(n)
+	}
+
+	t := time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], 0, loc)
+
+	return &object.Time{Value: t}
+}
+
+func timeFromUnix(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 1, 2); err != nil {
+		return err
+	}
+	seconds, err := NumberArgument("time.from_unix", args, 0)
+	if err != nil {
+		return err
+	}
+	loc, err := LocationArgument("time.from_unix", args, 1, time.Local)
+	if err != nil {
+		return err
+	}
+
+	var t time.Time
+	if i, ok := args[0].(*object.Integer); ok {
+		t = time.Unix(i.Value, 0)
+	} else {
+		t = time.Unix(0, int64(seconds*float64(time.Second)))
+	}
+
+	return &object.Time{Value: t.In(loc)}
+}
+
+func timeInZone(env *object.Environment, args ...object.Object) object.Object {
+	if err := CheckArgumentCount(args, 2, 2); err != nil {
+		return err
+	}
+	t, err := TimeArgument("time.in_zone", args, 0)
+	if err != nil {
+		return err
+	}
+	loc, err := LocationArgument("time.in_zone", args, 1, nil)
+	if err != nil {
+		return err
+	}
+
+	return &object.Time{Value: t.In(loc)}
+}
@@ -1,6 +1,6 @@
 module doge
 
-go 1.16
+go 1.20
 
 require (
 	github.com/carmark/pseudo-terminal-go v0.0.0-20151106093136-5a48ae24c6f5
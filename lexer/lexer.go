@@ -40,9 +40,15 @@ func (l *Lexer) PeekChar() byte {
 }
 
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
+	newLine := l.SkipWhitespace()
+	tok := l.readToken()
+	tok.NewLine = newLine
+
+	return tok
+}
 
-	l.SkipWhitespace()
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
 
 	switch l.ch {
 	case '=':
@@ -51,6 +57,11 @@ func (l *Lexer) NextToken() token.Token {
 			l.ReadChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.EQUAL, Literal: literal}
+		} else if l.PeekChar() == '>' {
+			ch := l.ch
+			l.ReadChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.ARROW, Literal: literal}
 		} else {
 			tok = NewToken(token.ASSIGN, l.ch)
 		}
@@ -162,6 +173,11 @@ func (l *Lexer) NextToken() token.Token {
 			l.ReadChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.LOR, Literal: literal}
+		} else if l.PeekChar() == '>' {
+			ch := l.ch
+			l.ReadChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.PIPELINE, Literal: literal}
 		} else {
 			tok = NewToken(token.PIPE, l.ch)
 		}
@@ -169,6 +185,8 @@ func (l *Lexer) NextToken() token.Token {
 		tok = NewToken(token.SEMICOLON, l.ch)
 	case ':':
 		tok = NewToken(token.COLON, l.ch)
+	case '?':
+		tok = NewToken(token.QUESTION, l.ch)
 	case ',':
 		tok = NewToken(token.COMMA, l.ch)
 	case '(':
@@ -183,11 +201,15 @@ func (l *Lexer) NextToken() token.Token {
 		tok.Literal = ""
 		tok.Type = token.EOF
 	default:
-		if IsLetter(l.ch) {
+		if l.ch == 'f' && l.PeekChar() == '"' {
+			l.ReadChar()
+			tok.Type = token.FSTRING
+			tok.Literal = l.ReadInterpolatedString()
+		} else if IsLetter(l.ch) {
 			tok.Literal = l.ReadIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
-		} else if IsDigit(l.ch) || l.ch == '.' {
+		} else if IsDigit(l.ch) || (l.ch == '.' && IsDigit(l.PeekChar())) {
 			tok.Type = token.INT
 			tok.Literal = l.ReadNumber()
 
@@ -196,6 +218,8 @@ func (l *Lexer) NextToken() token.Token {
 			}
 
 			return tok
+		} else if l.ch == '.' {
+			tok = NewToken(token.DOT, l.ch)
 		} else {
 			tok = NewToken(token.ILLEGAL, l.ch)
 		}
@@ -248,10 +272,58 @@ func (l *Lexer) ReadString() string {
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) SkipWhitespace() {
+// ReadInterpolatedString reads the body of an f-string. Quotes inside of
+// `{...}` belong to the embedded expression and don't end the string.
+func (l *Lexer) ReadInterpolatedString() string {
+	position := l.position + 1
+	depth := 0
+	inString := false
+
+	for {
+		l.ReadChar()
+		if l.ch == 0 {
+			break
+		}
+
+		if depth == 0 {
+			if l.ch == '"' {
+				break
+			}
+
+			if (l.ch == '{' || l.ch == '}') && l.PeekChar() == l.ch {
+				l.ReadChar()
+			} else if l.ch == '{' {
+				depth++
+			}
+
+			continue
+		}
+
+		switch {
+		case inString:
+			inString = l.ch != '"'
+		case l.ch == '"':
+			inString = true
+		case l.ch == '{':
+			depth++
+		case l.ch == '}':
+			depth--
+		}
+	}
+
+	return l.input[position:l.position]
+}
+
+// SkipWhitespace skips to the next token and reports whether it skipped a
+// line break.
+func (l *Lexer) SkipWhitespace() bool {
+	newLine := false
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+		newLine = newLine || l.ch == '\n'
 		l.ReadChar()
 	}
+
+	return newLine
 }
 
 func NewToken(tokenType token.TokenType, ch byte) token.Token {
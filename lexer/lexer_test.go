@@ -126,3 +126,184 @@ if (5 < 10) {
 		}
 	}
 }
+
+func TestNextTokenIn(t *testing.T) {
+	input := `for (x in xs) { 1 in s }`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FOR, "for"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.IN, "in"},
+		{token.IDENT, "xs"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.INT, "1"},
+		{token.IN, "in"},
+		{token.IDENT, "s"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenMatch(t *testing.T) {
+	input := `match (x) { case 1 => 2 }`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.MATCH, "match"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.CASE, "case"},
+		{token.INT, "1"},
+		{token.ARROW, "=>"},
+		{token.INT, "2"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenInterpolatedString(t *testing.T) {
+	input := `f"a {h["b"]} {{c}}" foo "d"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FSTRING, `a {h["b"]} {{c}}`},
+		{token.IDENT, "foo"},
+		{token.STRING, "d"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenDot(t *testing.T) {
+	input := `math.pi .5 1.5 re.match`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "math"},
+		{token.DOT, "."},
+		{token.IDENT, "pi"},
+		{token.FLOAT, ".5"},
+		{token.FLOAT, "1.5"},
+		{token.IDENT, "re"},
+		{token.DOT, "."},
+		{token.MATCH, "match"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenNewLine(t *testing.T) {
+	input := "a = [1]\n[b] = a  [c]\r\n\t\n  x"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedNewLine bool
+	}{
+		{token.IDENT, false},
+		{token.ASSIGN, false},
+		{token.LBRAKET, false},
+		{token.INT, false},
+		{token.RBRAKET, false},
+		{token.LBRAKET, true},
+		{token.IDENT, false},
+		{token.RBRAKET, false},
+		{token.ASSIGN, false},
+		{token.IDENT, false},
+		{token.LBRAKET, false},
+		{token.IDENT, false},
+		{token.RBRAKET, false},
+		{token.IDENT, true},
+		{token.EOF, false},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.NewLine != tt.expectedNewLine {
+			t.Fatalf("test[%d] - newline wrong. expected=%t, got=%t",
+				i, tt.expectedNewLine, tok.NewLine)
+		}
+	}
+}
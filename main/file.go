@@ -7,14 +7,15 @@ import (
 	"doge/parser"
 	"fmt"
 	"io/ioutil"
-	"os"
 )
 
-func RunFile(path string) {
-	buf, err := ioutil.ReadFile(path)
+// RunFile runs the script at args[0], passing all of args to it as argv,
+// and returns the exit code for the process.
+func RunFile(args []string) int {
+	buf, err := ioutil.ReadFile(args[0])
 	if err != nil {
 		fmt.Println("Couldn't read file! Aborting")
-		os.Exit(0)
+		return 1
 	}
 
 	data := string(buf)
@@ -26,15 +27,23 @@ func RunFile(path string) {
 		fmt.Println("Whoops such errors. Wow!!")
 		fmt.Println("Syntax Errors:")
 		PrintParserErrors(p.Errors())
-		os.Exit(0)
+		return 1
 	}
 
 	env := object.NewEnvironment()
+	env.Context().Args = args
 	env.Set("__name__", &object.String{Value: "__main__"})
 	evaluator.InitBuiltins()
 
 	res := evaluator.Eval(program, env)
-	if evaluator.IsError(res) {
+	if err, ok := res.(*object.Error); ok {
+		if err.Exit {
+			return err.Code
+		}
+
 		fmt.Println(res.Inspect())
+		return 1
 	}
+
+	return 0
 }
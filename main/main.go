@@ -6,7 +6,7 @@ import (
 
 func main() {
 	if len(os.Args) > 1 {
-		RunFile(os.Args[1])
+		os.Exit(RunFile(os.Args[1:]))
 	} else {
 		StartInteractiveShell()
 	}
@@ -1,13 +1,14 @@
 package main
 
 import (
-	"bufio"
+	"doge/ast"
 	"doge/evaluator"
 	"doge/lexer"
 	"doge/object"
 	"doge/parser"
 	"fmt"
 	"os"
+	"strings"
 )
 
 var CommitId string
@@ -19,19 +20,19 @@ var history []string
 func StartInteractiveShell() {
 	fmt.Println(DogeHeader)
 
-	scanner := bufio.NewScanner(os.Stdin)
 	env := object.NewEnvironment()
 	env.Set("__name__", &object.String{Value: "__main__"})
 	evaluator.InitBuiltins()
 
 	for {
 		fmt.Print(">>> ")
-		scanned := scanner.Scan()
-		if !scanned {
+		// read through the interpreter's stdin so `input()` sees the same data
+		line, err := env.Context().Stdin.ReadString('\n')
+		if err != nil && line == "" {
 			return
 		}
 
-		line := scanner.Text()
+		line = strings.TrimRight(line, "\r\n")
 		l := lexer.New(line)
 		p := parser.New(l)
 
@@ -44,7 +45,11 @@ func StartInteractiveShell() {
 		}
 
 		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil && evaluated.Type() != object.NULL_OBJ {
+		if err, ok := evaluated.(*object.Error); ok && err.Exit {
+			os.Exit(err.Code)
+		}
+
+		if evaluated != nil && evaluated.Type() != object.NULL_OBJ && !EndsWithAssignment(program) {
 			fmt.Println(evaluated.Inspect())
 		}
 
@@ -52,6 +57,22 @@ func StartInteractiveShell() {
 	}
 }
 
+// EndsWithAssignment reports whether the last statement of the program is
+// an assignment, whose value the shell doesn't echo.
+func EndsWithAssignment(program *ast.Program) bool {
+	if len(program.Statements) == 0 {
+		return false
+	}
+
+	stmt, ok := program.Statements[len(program.Statements)-1].(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+
+	_, ok = stmt.Expression.(*ast.AssignExpression)
+	return ok
+}
+
 func PrintParserErrors(errors []string) {
 	for _, msg := range errors {
 		fmt.Printf("  %s\n", msg)
@@ -1,12 +1,20 @@
 package object
 
 import (
+	"bufio"
 	"bytes"
 	"doge/ast"
+	"encoding/binary"
 	"fmt"
 	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ObjectType string
@@ -24,6 +32,12 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	SET_OBJ          = "SET"
+	MODULE_OBJ       = "MODULE"
+	ITERATOR_OBJ     = "ITERATOR"
+	PATTERN_OBJ      = "PATTERN"
+	TIME_OBJ         = "TIME"
+	DURATION_OBJ     = "DURATION"
 )
 
 type Object interface {
@@ -98,8 +112,12 @@ func (br *Break) Inspect() string {
 	return "BREAK"
 }
 
+// Error stops evaluation. Exit is set for errors raised by `os.exit`,
+// which unwind like any other error but end the program with Code.
 type Error struct {
 	Message string
+	Exit    bool
+	Code    int
 }
 
 func (e *Error) Type() ObjectType {
@@ -109,10 +127,39 @@ func (e *Error) Inspect() string {
 	return fmt.Sprintf("ERROR: %s", e.Message)
 }
 
+// Context holds the state shared by every environment of one interpreter.
+// Stdin is buffered, so everything reading input, including the shell,
+// must share it to not lose buffered data.
+//
+// Hosts embedding the interpreter can turn off modules like process for
+// sandboxed scripts by adding their names to DisabledModules.
+type Context struct {
+	Args            []string
+	Stdin           *bufio.Reader
+	Stdout          io.Writer
+	Stderr          io.Writer
+	Random          *rand.Rand
+	DisabledModules map[string]bool
+}
+
+func NewContext() *Context {
+	return &Context{
+		Stdin:  bufio.NewReader(os.Stdin),
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
 type Environment struct {
-	store map[string]Object
-	outer *Environment
-	loop  bool
+	store   map[string]Object
+	outer   *Environment
+	loop    bool
+	context *Context
+}
+
+func (e *Environment) Context() *Context {
+	return e.context
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -122,10 +169,13 @@ func (e *Environment) Get(name string) (Object, bool) {
 	}
 	return obj, ok
 }
+
+// Set binds name in this environment. Loop environments assign to an
+// enclosing variable of the same name instead, however far out it is.
 func (e *Environment) Set(name string, val Object) Object {
-	if e.outer != nil {
-		if _, ok := e.outer.Get(name); ok && e.loop {
-			e.outer.store[name] = val
+	if _, local := e.store[name]; !local && e.loop && e.outer != nil {
+		if _, ok := e.outer.Get(name); ok {
+			return e.outer.Set(name, val)
 		}
 	}
 
@@ -133,17 +183,31 @@ func (e *Environment) Set(name string, val Object) Object {
 	return val
 }
 
+// SetLocal binds name in this environment only, even if an enclosing loop
+// environment already knows it.
+func (e *Environment) SetLocal(name string, val Object) Object {
+	e.store[name] = val
+	return val
+}
+
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil, loop: false}
+	return &Environment{store: s, outer: nil, loop: false, context: NewContext()}
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
+	env := NewFunctionEnvironment(outer)
 	env.outer = outer
 	return env
 }
 
+// NewFunctionEnvironment returns an environment without variables that
+// shares the interpreter context of caller.
+func NewFunctionEnvironment(caller *Environment) *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s, outer: nil, loop: false, context: caller.context}
+}
+
 func NewPartiallyEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnclosedEnvironment(outer)
 	env.loop = true
@@ -201,8 +265,92 @@ func (b *Builtin) Inspect() string {
 	return "builtin function"
 }
 
+// Module is a named collection of builtins and constants, such as `math`.
+type Module struct {
+	Name    string
+	Members map[string]Object
+}
+
+func (m *Module) Type() ObjectType {
+	return MODULE_OBJ
+}
+func (m *Module) Inspect() string {
+	return "<module " + m.Name + ">"
+}
+
+// Iterator produces values one at a time, such as the lines of a file, and
+// can only be walked once. Next reports false when there are no more
+// values; an *Error value ends the iteration with that error. Close, if
+// set, releases resources when iteration stops before the end.
+type Iterator struct {
+	Name  string
+	Next  func() (Object, bool)
+	Close func()
+}
+
+func (it *Iterator) Type() ObjectType {
+	return ITERATOR_OBJ
+}
+func (it *Iterator) Inspect() string {
+	return "<iterator " + it.Name + ">"
+}
+
+// Pattern is a compiled regular expression returned by `re.compile`.
+type Pattern struct {
+	Regexp *regexp.Regexp
+}
+
+func (p *Pattern) Type() ObjectType {
+	return PATTERN_OBJ
+}
+func (p *Pattern) Inspect() string {
+	return "re.compile(" + strconv.Quote(p.Regexp.String()) + ")"
+}
+
+// Time is a point in time with a time zone, see the time module.
+type Time struct {
+	Value time.Time
+}
+
+func (t *Time) Type() ObjectType {
+	return TIME_OBJ
+}
+func (t *Time) Inspect() string {
+	return t.Value.Format(time.RFC3339Nano)
+}
+
+type Duration struct {
+	Value time.Duration
+}
+
+func (d *Duration) Type() ObjectType {
+	return DURATION_OBJ
+}
+func (d *Duration) Inspect() string {
+	return d.Value.String()
+}
+
 type Array struct {
 	Elements []Object
+	Frozen   bool
+}
+
+// Freeze returns a frozen copy of the array. Nested arrays are frozen as
+// well, so the copy can safely be used as a hash key.
+func (ao *Array) Freeze() *Array {
+	if ao.Frozen {
+		return ao
+	}
+
+	elements := make([]Object, len(ao.Elements))
+	for i, e := range ao.Elements {
+		if arr, ok := e.(*Array); ok {
+			e = arr.Freeze()
+		}
+		elements[i] = e
+	}
+
+	return &Array{Elements: elements, Frozen: true}
 }
 
 func (ao *Array) Type() ObjectType {
@@ -226,6 +374,23 @@ func (ao *Array) Inspect() string {
 type HashKey struct {
 	Type  ObjectType
 	Value uint64
+	Probe uint64
+}
+
+// IsHashable reports whether obj can be used as a hash key. Arrays are
+// hashable if all of their elements are.
+func IsHashable(obj Object) bool {
+	if arr, ok := obj.(*Array); ok {
+		for _, e := range arr.Elements {
+			if !IsHashable(e) {
+				return false
+			}
+		}
+		return true
+	}
+
+	_, ok := obj.(Hashable)
+	return ok
 }
 
 func (b *Boolean) HashKey() HashKey {
@@ -251,6 +416,47 @@ func (s *String) HashKey() HashKey {
 	return HashKey{Type: s.Type(), Value: h.Sum64()}
 }
 
+// HashKey of a float with an integral value is the same as the one of the
+// matching integer, so 1.0 and 1 address the same hash entry.
+func (f *Float) HashKey() HashKey {
+	if isIntegral(f.Value) {
+		return (&Integer{Value: int64(f.Value)}).HashKey()
+	}
+
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+// isIntegral reports whether f is a whole number in the range of int64.
+func isIntegral(f float64) bool {
+	return f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64
+}
+
+// integerEqualsFloat compares exactly, without rounding i to a float, so
+// numbers that are equal also have the same hash key.
+func integerEqualsFloat(i int64, f float64) bool {
+	return isIntegral(f) && int64(f) == i
+}
+
+func (n *Null) HashKey() HashKey {
+	return HashKey{Type: n.Type(), Value: 0}
+}
+
+func (ao *Array) HashKey() HashKey {
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+
+	for _, e := range ao.Elements {
+		if hashable, ok := e.(Hashable); ok {
+			key := hashable.HashKey()
+			h.Write([]byte(key.Type))
+			binary.LittleEndian.PutUint64(buf, key.Value)
+			h.Write(buf)
+		}
+	}
+
+	return HashKey{Type: ao.Type(), Value: h.Sum64()}
+}
+
 type HashPair struct {
 	Key   Object
 	Value Object
@@ -258,6 +464,119 @@ type HashPair struct {
 
 type Hash struct {
 	Pairs map[HashKey]HashPair
+	order []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+// slot finds the entry for key. Keys whose hash values collide are stored
+// under increasing Probe values, so the chain is walked until either an
+// equal key or a free slot is found.
+func (h *Hash) slot(key Object) (HashKey, bool) {
+	hashKey := key.(Hashable).HashKey()
+
+	for {
+		pair, ok := h.Pairs[hashKey]
+		if !ok {
+			return hashKey, false
+		}
+
+		if Equal(pair.Key, key) {
+			return hashKey, true
+		}
+
+		hashKey.Probe++
+	}
+}
+
+// Get returns the value stored for key. The key must be hashable.
+func (h *Hash) Get(key Object) (Object, bool) {
+	hashKey, ok := h.slot(key)
+	if !ok {
+		return nil, false
+	}
+
+	return h.Pairs[hashKey].Value, true
+}
+
+// Set stores value for key. The key must be hashable. Arrays are frozen
+// before they are stored so later changes can't corrupt the hash.
+func (h *Hash) Set(key, value Object) {
+	if h.Pairs == nil {
+		h.Pairs = make(map[HashKey]HashPair)
+	}
+
+	if arr, ok := key.(*Array); ok {
+		key = arr.Freeze()
+	}
+
+	hashKey, ok := h.slot(key)
+	if ok {
+		key = h.Pairs[hashKey].Key
+	} else {
+		h.order = append(h.order, hashKey)
+	}
+
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+// Delete removes key from the hash and returns its value. Entries further
+// along the same probe chain are moved back one step, so lookups for them
+// don't stop early at the freed slot.
+func (h *Hash) Delete(key Object) (Object, bool) {
+	hashKey, ok := h.slot(key)
+	if !ok {
+		return nil, false
+	}
+
+	value := h.Pairs[hashKey].Value
+	delete(h.Pairs, hashKey)
+	h.order = removeHashKey(h.order, hashKey)
+
+	for {
+		next := hashKey
+		next.Probe++
+
+		pair, ok := h.Pairs[next]
+		if !ok {
+			break
+		}
+
+		delete(h.Pairs, next)
+		h.Pairs[hashKey] = pair
+		for i := range h.order {
+			if h.order[i] == next {
+				h.order[i] = hashKey
+			}
+		}
+
+		hashKey = next
+	}
+
+	return value, true
+}
+
+func removeHashKey(keys []HashKey, key HashKey) []HashKey {
+	for i := range keys {
+		if keys[i] == key {
+			return append(keys[:i], keys[i+1:]...)
+		}
+	}
+
+	return keys
+}
+
+// Ordered returns the pairs of the hash in insertion order.
+func (h *Hash) Ordered() []HashPair {
+	pairs := make([]HashPair, 0, len(h.order))
+
+	for _, hashKey := range h.order {
+		pairs = append(pairs, h.Pairs[hashKey])
+	}
+
+	return pairs
 }
 
 func (h *Hash) Type() ObjectType {
@@ -267,7 +586,7 @@ func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
+	for _, pair := range h.Ordered() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
@@ -277,3 +596,236 @@ func (h *Hash) Inspect() string {
 
 	return out.String()
 }
+
+// Set is an unordered collection of unique hashable objects. Elements are
+// kept in insertion order, the same way as hash keys.
+type Set struct {
+	items *Hash
+}
+
+func NewSet() *Set {
+	return &Set{items: NewHash()}
+}
+
+func (s *Set) Type() ObjectType {
+	return SET_OBJ
+}
+func (s *Set) Inspect() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range s.Elements() {
+		elements = append(elements, e.Inspect())
+	}
+
+	out.WriteString("set(")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// Add inserts obj into the set. The object must be hashable.
+func (s *Set) Add(obj Object) {
+	if !s.Contains(obj) {
+		s.items.Set(obj, obj)
+	}
+}
+
+func (s *Set) Contains(obj Object) bool {
+	_, ok := s.items.Get(obj)
+	return ok
+}
+
+func (s *Set) Len() int {
+	return len(s.items.Pairs)
+}
+
+// Elements returns the elements of the set in insertion order.
+func (s *Set) Elements() []Object {
+	elements := []Object{}
+
+	for _, pair := range s.items.Ordered() {
+		elements = append(elements, pair.Key)
+	}
+
+	return elements
+}
+
+// Equal reports whether two objects are structurally equal. Numbers compare
+// by value across INTEGER and FLOAT, arrays and hashes compare element by
+// element and functions compare by identity. Arrays and hashes may contain
+// themselves, so the pairs being compared are tracked.
+func Equal(a, b Object) bool {
+	return equal(a, b, map[[2]Object]bool{})
+}
+
+func equal(a, b Object, seen map[[2]Object]bool) bool {
+	switch a := a.(type) {
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == b.Value
+		case *Float:
+			return integerEqualsFloat(a.Value, b.Value)
+		}
+		return false
+	case *Float:
+		switch b := b.(type) {
+		case *Integer:
+			return integerEqualsFloat(b.Value, a.Value)
+		case *Float:
+			return a.Value == b.Value
+		}
+		return false
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		// a pair met again is being compared further up, and is equal
+		// unless that comparison finds a difference
+		if a == b || seen[[2]Object{a, b}] {
+			return true
+		}
+		seen[[2]Object{a, b}] = true
+
+		for i := range a.Elements {
+			if !equal(a.Elements[i], b.Elements[i], seen) {
+				return false
+			}
+		}
+
+		return true
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || len(a.Pairs) != len(b.Pairs) {
+			return false
+		}
+		if a == b || seen[[2]Object{a, b}] {
+			return true
+		}
+		seen[[2]Object{a, b}] = true
+
+		for _, pair := range a.Pairs {
+			other, ok := b.Get(pair.Key)
+			if !ok || !equal(pair.Value, other, seen) {
+				return false
+			}
+		}
+
+		return true
+	case *Time:
+		b, ok := b.(*Time)
+		return ok && a.Value.Equal(b.Value)
+	case *Duration:
+		b, ok := b.(*Duration)
+		return ok && a.Value == b.Value
+	case *Set:
+		b, ok := b.(*Set)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+
+		for _, e := range a.Elements() {
+			if !b.Contains(e) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return a == b
+}
+
+// Compare orders two objects, returning -1, 0 or 1. Numbers are ordered by
+// value, strings and arrays lexicographically. The second return value is
+// false if the objects cannot be ordered.
+func Compare(a, b Object) (int, bool) {
+	switch a := a.(type) {
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return compareInts(a.Value, b.Value), true
+		case *Float:
+			return compareFloats(float64(a.Value), b.Value), true
+		}
+	case *Float:
+		switch b := b.(type) {
+		case *Integer:
+			return compareFloats(a.Value, float64(b.Value)), true
+		case *Float:
+			return compareFloats(a.Value, b.Value), true
+		}
+	case *String:
+		if b, ok := b.(*String); ok {
+			return strings.Compare(a.Value, b.Value), true
+		}
+	case *Time:
+		if b, ok := b.(*Time); ok {
+			switch {
+			case a.Value.Before(b.Value):
+				return -1, true
+			case a.Value.After(b.Value):
+				return 1, true
+			}
+			return 0, true
+		}
+	case *Duration:
+		if b, ok := b.(*Duration); ok {
+			return compareInts(int64(a.Value), int64(b.Value)), true
+		}
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok {
+			return 0, false
+		}
+
+		for i := 0; i < len(a.Elements) && i < len(b.Elements); i++ {
+			result, ok := Compare(a.Elements[i], b.Elements[i])
+			if !ok {
+				return 0, false
+			}
+
+			if result != 0 {
+				return result, true
+			}
+		}
+
+		return compareInts(int64(len(a.Elements)), int64(len(b.Elements))), true
+	}
+
+	return 0, false
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -20,3 +20,80 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have the same hash keys")
 	}
 }
+
+func TestNumericHashKeys(t *testing.T) {
+	if (&Float{Value: 1.0}).HashKey() != (&Integer{Value: 1}).HashKey() {
+		t.Errorf("integral float and integer have different hash keys")
+	}
+
+	if (&Float{Value: 1.5}).HashKey() == (&Integer{Value: 1}).HashKey() {
+		t.Errorf("non integral float has the same hash key as integer")
+	}
+}
+
+func TestArrayHashKey(t *testing.T) {
+	point1 := &Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}
+	point2 := &Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}
+	diff := &Array{Elements: []Object{&Integer{Value: 2}, &Integer{Value: 1}}}
+
+	if point1.HashKey() != point2.HashKey() {
+		t.Errorf("arrays with same content have different hash keys")
+	}
+
+	if point1.HashKey() == diff.HashKey() {
+		t.Errorf("arrays with different content have the same hash keys")
+	}
+}
+
+func TestHashKeyCollision(t *testing.T) {
+	hash := NewHash()
+	a := &String{Value: "a"}
+	b := &String{Value: "b"}
+
+	// store "a" where "b" would go to simulate a collision
+	hash.Pairs[b.HashKey()] = HashPair{Key: a, Value: &Integer{Value: 1}}
+	hash.Set(b, &Integer{Value: 2})
+
+	if len(hash.Pairs) != 2 {
+		t.Fatalf("colliding key overwrote existing pair. got=%d pairs", len(hash.Pairs))
+	}
+
+	value, ok := hash.Get(b)
+	if !ok || value.(*Integer).Value != 2 {
+		t.Errorf("wrong value for colliding key. got=%v", value)
+	}
+}
+
+func TestHashDeleteCollision(t *testing.T) {
+	hash := NewHash()
+	a := &String{Value: "a"}
+	b := &String{Value: "b"}
+	c := &String{Value: "c"}
+
+	// chain "a", "b" and "c" in the slots of "b" to simulate collisions
+	hashKey := b.HashKey()
+	hash.Pairs[hashKey] = HashPair{Key: a, Value: &Integer{Value: 1}}
+	hash.order = append(hash.order, hashKey)
+	hash.Set(b, &Integer{Value: 2})
+	hashKey.Probe = 2
+	hash.Pairs[hashKey] = HashPair{Key: c, Value: &Integer{Value: 3}}
+	hash.order = append(hash.order, hashKey)
+
+	if value, ok := hash.Delete(b); !ok || value.(*Integer).Value != 2 {
+		t.Fatalf("wrong deleted value. got=%v", value)
+	}
+
+	if _, ok := hash.Get(b); ok {
+		t.Errorf("deleted key is still present")
+	}
+
+	hashKey.Probe = 1
+	if pair, ok := hash.Pairs[hashKey]; !ok || pair.Key != c {
+		t.Errorf("rest of the probe chain was not moved back. got=%v", hash.Pairs)
+	}
+
+	pairs := hash.Ordered()
+	if len(pairs) != 2 || pairs[0].Key != a || pairs[1].Key != c {
+		t.Errorf("wrong order after delete. got=%v", pairs)
+	}
+}
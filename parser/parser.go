@@ -6,14 +6,18 @@ import (
 	"doge/token"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN
+	TERNARY
 	AND_OR
 	EQUALS
 	LESS_GREATER
+	PIPELINE
 	SUM
 	PRODUCT
 	PREFIX
@@ -23,7 +27,12 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
+	token.ASSIGN:   ASSIGN,
+	token.PIPELINE: PIPELINE,
+	token.QUESTION: TERNARY,
 	token.EQUAL:    EQUALS,
+	token.IN:       EQUALS,
+	token.NOT:      EQUALS,
 	token.UNEQUAL:  EQUALS,
 	token.LT:       LESS_GREATER,
 	token.GT:       LESS_GREATER,
@@ -44,6 +53,7 @@ var precedences = map[token.TokenType]int{
 	token.POWER:    POWER,
 	token.LPAREN:   CALL,
 	token.LBRAKET:  INDEX,
+	token.DOT:      INDEX,
 }
 
 type (
@@ -58,6 +68,11 @@ type Parser struct {
 	curToken  token.Token
 	peekToken token.Token
 
+	// inPattern disables arrow functions while parsing match patterns and
+	// guards, where `=>` separates them from the case body. Brackets turn
+	// them back on, as `=>` can't end the case inside them.
+	inPattern bool
+
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
@@ -74,6 +89,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.RegisterPrefix(token.MINUS, p.ParsePrefixExpression)
 	p.RegisterPrefix(token.BANG, p.ParsePrefixExpression)
 	p.RegisterPrefix(token.STRING, p.ParseStringLiteral)
+	p.RegisterPrefix(token.FSTRING, p.ParseInterpolatedString)
 	p.RegisterPrefix(token.LBRAKET, p.ParseArrayLiteral)
 	p.RegisterPrefix(token.INT, p.ParseIntegerLiteral)
 	p.RegisterPrefix(token.FLOAT, p.ParseFloatLiteral)
@@ -82,6 +98,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.RegisterPrefix(token.IF, p.ParseIfExpression)
 	p.RegisterPrefix(token.WHILE, p.ParseWhileExpression)
 	p.RegisterPrefix(token.FOR, p.ParseForExpression)
+	p.RegisterPrefix(token.MATCH, p.ParseMatchExpression)
 	p.RegisterPrefix(token.FALSE, p.ParseBoolean)
 	p.RegisterPrefix(token.TRUE, p.ParseBoolean)
 
@@ -89,6 +106,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.RegisterInfix(token.ASTERISK, p.ParseInfixExpression)
 	p.RegisterInfix(token.UNEQUAL, p.ParseInfixExpression)
 	p.RegisterInfix(token.LBRAKET, p.ParseIndexExpression)
+	p.RegisterInfix(token.DOT, p.ParseMemberExpression)
 	p.RegisterInfix(token.MODULO, p.ParseInfixExpression)
 	p.RegisterInfix(token.SHIFTL, p.ParseInfixExpression)
 	p.RegisterInfix(token.SHIFTR, p.ParseInfixExpression)
@@ -107,6 +125,11 @@ func New(l *lexer.Lexer) *Parser {
 	p.RegisterInfix(token.AND, p.ParseInfixExpression)
 	p.RegisterInfix(token.LT, p.ParseInfixExpression)
 	p.RegisterInfix(token.GT, p.ParseInfixExpression)
+	p.RegisterInfix(token.IN, p.ParseInfixExpression)
+	p.RegisterInfix(token.NOT, p.ParseNotInExpression)
+	p.RegisterInfix(token.ASSIGN, p.ParseAssignExpression)
+	p.RegisterInfix(token.QUESTION, p.ParseConditionalExpression)
+	p.RegisterInfix(token.PIPELINE, p.ParseInfixExpression)
 
 	p.NextToken()
 	p.NextToken()
@@ -179,18 +202,30 @@ func (p *Parser) ParseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.curToken}
 	hash.Pairs = make(map[ast.Expression]ast.Expression)
 
+	inPattern := p.inPattern
+	p.inPattern = false
+	defer func() { p.inPattern = inPattern }()
+
 	for !p.PeekTokenIs(token.RBRACE) {
 		p.NextToken()
 		key := p.ParseExpression(LOWEST)
+		var value ast.Expression
 
-		if !p.ExpectPeek(token.COLON) {
-			return nil
-		}
+		// {name} is shorthand for {"name": name}
+		if ident, ok := key.(*ast.Identifier); ok && (p.PeekTokenIs(token.COMMA) || p.PeekTokenIs(token.RBRACE)) {
+			key = &ast.StringLiteral{Token: ident.Token, Value: ident.Value}
+			value = ident
+		} else {
+			if !p.ExpectPeek(token.COLON) {
+				return nil
+			}
 
-		p.NextToken()
-		value := p.ParseExpression(LOWEST)
+			p.NextToken()
+			value = p.ParseExpression(LOWEST)
+		}
 
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.PeekTokenIs(token.RBRACE) && !p.ExpectPeek(token.COMMA) {
 			return nil
@@ -207,6 +242,10 @@ func (p *Parser) ParseHashLiteral() ast.Expression {
 func (p *Parser) ParseExpressionList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
+	inPattern := p.inPattern
+	p.inPattern = false
+	defer func() { p.inPattern = inPattern }()
+
 	if p.PeekTokenIs(end) {
 		p.NextToken()
 		return list
@@ -247,21 +286,141 @@ func (p *Parser) ParseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
-func (p *Parser) ParseIdentifier() ast.Expression {
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+func (p *Parser) ParseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	input := p.curToken.Literal
 
-	if p.PeekTokenIs(token.ASSIGN) {
-		p.NextToken()
-		expression := &ast.AssignExpression{
-			Token: p.curToken,
-			Left:  ident,
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: text.String()})
+			str.Specs = append(str.Specs, "")
+			text.Reset()
 		}
+	}
 
-		precedence := p.CurPrecedence()
-		p.NextToken()
-		expression.Right = p.ParseExpression(precedence)
+	for i := 0; i < len(input); i++ {
+		ch := input[i]
+
+		switch {
+		case (ch == '{' || ch == '}') && i+1 < len(input) && input[i+1] == ch:
+			text.WriteByte(ch)
+			i++
+		case ch == '{':
+			end := FindClosingBrace(input, i)
+			if end < 0 {
+				p.errors = append(p.errors, "unterminated expression in f-string")
+				return nil
+			}
+
+			flush()
+
+			source, spec := SplitFormatSpec(input[i+1 : end])
+			exp := p.ParseEmbeddedExpression(source)
+			if exp == nil {
+				return nil
+			}
+
+			str.Parts = append(str.Parts, exp)
+			str.Specs = append(str.Specs, spec)
+			i = end
+		case ch == '}':
+			p.errors = append(p.errors, "single '}' is not allowed in f-string")
+			return nil
+		default:
+			text.WriteByte(ch)
+		}
+	}
+
+	flush()
+
+	return str
+}
+
+// ParseEmbeddedExpression parses the source of an expression embedded in an
+// f-string with a parser of its own.
+func (p *Parser) ParseEmbeddedExpression(source string) ast.Expression {
+	sub := New(lexer.New(source))
+	if sub.CurTokenIs(token.EOF) {
+		p.errors = append(p.errors, "empty expression in f-string")
+		return nil
+	}
+
+	exp := sub.ParseExpression(LOWEST)
+	if !sub.PeekTokenIs(token.EOF) {
+		msg := fmt.Sprintf("unexpected %s in f-string expression %q", sub.peekToken.Type, source)
+		sub.errors = append(sub.errors, msg)
+	}
+
+	if len(sub.errors) != 0 {
+		p.errors = append(p.errors, sub.errors...)
+		return nil
+	}
+
+	return exp
+}
+
+// FindClosingBrace returns the index of the brace closing the one at start,
+// skipping braces in nested blocks and string literals, or -1.
+func FindClosingBrace(input string, start int) int {
+	depth := 0
+	inString := false
+
+	for i := start; i < len(input); i++ {
+		switch {
+		case inString:
+			inString = input[i] != '"'
+		case input[i] == '"':
+			inString = true
+		case input[i] == '{':
+			depth++
+		case input[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
 
-		return expression
+	return -1
+}
+
+// SplitFormatSpec splits `expr:spec` at the first colon that isn't nested
+// or part of a conditional expression.
+func SplitFormatSpec(source string) (string, string) {
+	depth := 0
+	pending := 0
+	inString := false
+
+	for i := 0; i < len(source); i++ {
+		switch ch := source[i]; {
+		case inString:
+			inString = ch != '"'
+		case ch == '"':
+			inString = true
+		case ch == '(' || ch == '[' || ch == '{':
+			depth++
+		case ch == ')' || ch == ']' || ch == '}':
+			depth--
+		case depth == 0 && ch == '?':
+			pending++
+		case depth == 0 && ch == ':':
+			if pending == 0 {
+				return source[:i], source[i+1:]
+			}
+			pending--
+		}
+	}
+
+	return source, ""
+}
+
+func (p *Parser) ParseIdentifier() ast.Expression {
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.PeekTokenIs(token.ARROW) && !p.inPattern {
+		p.NextToken()
+		return p.ParseArrowFunction([]*ast.Identifier{ident})
 	}
 
 	return ident
@@ -276,6 +435,12 @@ func (p *Parser) ParseExpression(precedence int) ast.Expression {
 	leftExp := prefix()
 
 	for !p.PeekTokenIs(token.SEMICOLON) && precedence < p.PeekPrecedence() {
+		// a bracket starting a line begins the next statement, like the
+		// array pattern in `[x, y] = point`, instead of indexing this one
+		if p.PeekTokenIs(token.LBRAKET) && p.peekToken.NewLine {
+			return leftExp
+		}
+
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
 			return leftExp
@@ -301,6 +466,22 @@ func (p *Parser) ParseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+// ParseMemberExpression parses `object.name`. Keywords are allowed as names,
+// so members like `re.match` can be used.
+func (p *Parser) ParseMemberExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: left}
+
+	p.NextToken()
+	if p.curToken.Literal == "" || !lexer.IsLetter(p.curToken.Literal[0]) {
+		p.errors = append(p.errors, fmt.Sprintf("expected member name after '.', got %s instead", p.curToken.Type))
+		return nil
+	}
+
+	exp.Member = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
 func (p *Parser) ParsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -328,9 +509,15 @@ func (p *Parser) ParseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
-func (p *Parser) ParseAssignExpression() ast.Expression {
-	expression := &ast.AssignExpression{
-		Token: p.curToken,
+func (p *Parser) ParseNotInExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: "not in",
+		Left:     left,
+	}
+
+	if !p.ExpectPeek(token.IN) {
+		return nil
 	}
 
 	precedence := p.CurPrecedence()
@@ -340,6 +527,82 @@ func (p *Parser) ParseAssignExpression() ast.Expression {
 	return expression
 }
 
+func (p *Parser) ParseAssignExpression(left ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token: p.curToken,
+		Left:  left,
+	}
+
+	p.NextToken()
+	expression.Right = p.ParseExpression(LOWEST)
+
+	return expression
+}
+
+func (p *Parser) ParseConditionalExpression(condition ast.Expression) ast.Expression {
+	expression := &ast.ConditionalExpression{
+		Token:     p.curToken,
+		Condition: condition,
+	}
+
+	p.NextToken()
+	expression.Consequence = p.ParseExpression(TERNARY)
+
+	if !p.ExpectPeek(token.COLON) {
+		return nil
+	}
+
+	// parsing the alternative below TERNARY makes `a ? b : c ? d : e`
+	// group to the right
+	p.NextToken()
+	expression.Alternative = p.ParseExpression(ASSIGN)
+
+	return expression
+}
+
+// ParseMultipleAssignment parses `a, b = b, a` after the first target has
+// been parsed. Both sides are collected into array literals.
+func (p *Parser) ParseMultipleAssignment(first ast.Expression) ast.Expression {
+	targets := &ast.ArrayLiteral{Token: p.curToken, Elements: []ast.Expression{first}}
+
+	for p.PeekTokenIs(token.COMMA) {
+		p.NextToken()
+		p.NextToken()
+		targets.Elements = append(targets.Elements, p.ParseExpression(ASSIGN))
+	}
+
+	if !p.ExpectPeek(token.ASSIGN) {
+		return nil
+	}
+
+	expression := &ast.AssignExpression{Token: p.curToken, Left: targets}
+
+	p.NextToken()
+	expression.Right = p.ParseExpressionSequence()
+
+	return expression
+}
+
+// ParseExpressionSequence parses comma separated expressions. More than one
+// expression is turned into an array literal.
+func (p *Parser) ParseExpressionSequence() ast.Expression {
+	tok := p.curToken
+	first := p.ParseExpression(LOWEST)
+
+	if !p.PeekTokenIs(token.COMMA) {
+		return first
+	}
+
+	array := &ast.ArrayLiteral{Token: tok, Elements: []ast.Expression{first}}
+	for p.PeekTokenIs(token.COMMA) {
+		p.NextToken()
+		p.NextToken()
+		array.Elements = append(array.Elements, p.ParseExpression(LOWEST))
+	}
+
+	return array
+}
+
 func (p *Parser) ParseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
@@ -401,6 +664,10 @@ func (p *Parser) ParseForExpression() ast.Expression {
 	}
 
 	p.NextToken()
+	if p.CurTokenIs(token.IDENT) && p.PeekTokenIs(token.IN) {
+		return p.ParseForInExpression(expression.Token)
+	}
+
 	expression.Initial = p.ParseExpression(LOWEST)
 
 	if !p.ExpectPeek(token.SEMICOLON) {
@@ -430,6 +697,27 @@ func (p *Parser) ParseForExpression() ast.Expression {
 	return expression
 }
 
+func (p *Parser) ParseForInExpression(tok token.Token) ast.Expression {
+	expression := &ast.ForInExpression{Token: tok}
+	expression.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	p.NextToken()
+	p.NextToken()
+	expression.Iterable = p.ParseExpression(LOWEST)
+
+	if !p.ExpectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.ExpectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Consequence = p.ParseBlockStatement()
+
+	return expression
+}
+
 func (p *Parser) ParseIfExpression() ast.Expression {
 	expression := &ast.IfExpression{Token: p.curToken}
 
@@ -463,6 +751,90 @@ func (p *Parser) ParseIfExpression() ast.Expression {
 	return expression
 }
 
+func (p *Parser) ParseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.ExpectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.NextToken()
+	expression.Value = p.ParseExpression(LOWEST)
+
+	if !p.ExpectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.ExpectPeek(token.LBRACE) {
+		return nil
+	}
+
+	catchAll := false
+	for !p.PeekTokenIs(token.RBRACE) {
+		if !p.ExpectPeek(token.CASE) {
+			return nil
+		}
+
+		if catchAll {
+			p.errors = append(p.errors, "unreachable case after catch-all pattern")
+			return nil
+		}
+
+		matchCase := p.ParseMatchCase()
+		if matchCase == nil {
+			return nil
+		}
+
+		if _, ok := matchCase.Pattern.(*ast.Identifier); ok && matchCase.Guard == nil {
+			catchAll = true
+		}
+
+		expression.Cases = append(expression.Cases, matchCase)
+
+		if p.PeekTokenIs(token.COMMA) || p.PeekTokenIs(token.SEMICOLON) {
+			p.NextToken()
+		}
+	}
+
+	p.NextToken()
+
+	return expression
+}
+
+func (p *Parser) ParseMatchCase() *ast.MatchCase {
+	matchCase := &ast.MatchCase{Token: p.curToken}
+
+	p.inPattern = true
+	defer func() { p.inPattern = false }()
+
+	p.NextToken()
+	matchCase.Pattern = p.ParseExpression(LOWEST)
+
+	if p.PeekTokenIs(token.IF) {
+		p.NextToken()
+		p.NextToken()
+		matchCase.Guard = p.ParseExpression(LOWEST)
+	}
+
+	p.inPattern = false
+
+	if !p.ExpectPeek(token.ARROW) {
+		return nil
+	}
+
+	if p.PeekTokenIs(token.LBRACE) {
+		p.NextToken()
+		matchCase.Body = p.ParseBlockStatement()
+		return matchCase
+	}
+
+	p.NextToken()
+	stmt := &ast.ExpressionStatement{Token: p.curToken, Expression: p.ParseExpression(LOWEST)}
+	matchCase.Body = &ast.BlockStatement{Token: stmt.Token, Statements: []ast.Statement{stmt}}
+
+	return matchCase
+}
+
 func (p *Parser) ParseFunctionLiteral() ast.Expression {
 	lit := &ast.FunctionLiteral{Token: p.curToken}
 
@@ -532,17 +904,90 @@ func (p *Parser) ParseBlockStatement() *ast.BlockStatement {
 }
 
 func (p *Parser) ParseGroupedExpression() ast.Expression {
+	if p.PeekTokenIs(token.RPAREN) {
+		p.NextToken()
+
+		if !p.ExpectPeek(token.ARROW) {
+			return nil
+		}
+
+		return p.ParseArrowFunction([]*ast.Identifier{})
+	}
+
 	p.NextToken()
 
+	inPattern := p.inPattern
+	p.inPattern = false
 	exp := p.ParseExpression(LOWEST)
+	p.inPattern = inPattern
+
+	if p.PeekTokenIs(token.COMMA) {
+		return p.ParseArrowFunctionParameters(exp)
+	}
 
 	if !p.ExpectPeek(token.RPAREN) {
 		return nil
 	}
 
+	if ident, ok := exp.(*ast.Identifier); ok && p.PeekTokenIs(token.ARROW) && !p.inPattern {
+		p.NextToken()
+		return p.ParseArrowFunction([]*ast.Identifier{ident})
+	}
+
 	return exp
 }
 
+// ParseArrowFunctionParameters parses `(a, b) => ...` after the first
+// parameter has been parsed as a grouped expression.
+func (p *Parser) ParseArrowFunctionParameters(first ast.Expression) ast.Expression {
+	exps := []ast.Expression{first}
+
+	for p.PeekTokenIs(token.COMMA) {
+		p.NextToken()
+		p.NextToken()
+		exps = append(exps, p.ParseExpression(LOWEST))
+	}
+
+	if !p.ExpectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.ExpectPeek(token.ARROW) {
+		return nil
+	}
+
+	params := []*ast.Identifier{}
+	for _, exp := range exps {
+		ident, ok := exp.(*ast.Identifier)
+		if !ok {
+			msg := fmt.Sprintf("invalid arrow function parameter: %s", exp)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		params = append(params, ident)
+	}
+
+	return p.ParseArrowFunction(params)
+}
+
+// ParseArrowFunction parses the body of `x => x * 2` or `(a, b) => { ... }`
+// with the current token on `=>`. An expression body is returned implicitly.
+func (p *Parser) ParseArrowFunction(params []*ast.Identifier) ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken, Parameters: params}
+
+	if p.PeekTokenIs(token.LBRACE) {
+		p.NextToken()
+		lit.Body = p.ParseBlockStatement()
+		return lit
+	}
+
+	p.NextToken()
+	stmt := &ast.ReturnStatement{Token: p.curToken, ReturnValue: p.ParseExpression(LOWEST)}
+	lit.Body = &ast.BlockStatement{Token: stmt.Token, Statements: []ast.Statement{stmt}}
+
+	return lit
+}
+
 func (p *Parser) ParseBoolean() ast.Expression {
 	return &ast.Boolean{
 		Token: p.curToken,
@@ -555,6 +1000,10 @@ func (p *Parser) ParseExpressionStatement() *ast.ExpressionStatement {
 
 	stmt.Expression = p.ParseExpression(LOWEST)
 
+	if p.PeekTokenIs(token.COMMA) {
+		stmt.Expression = p.ParseMultipleAssignment(stmt.Expression)
+	}
+
 	if p.PeekTokenIs(token.SEMICOLON) {
 		p.NextToken()
 	}
@@ -567,7 +1016,7 @@ func (p *Parser) ParseReturnStatement() *ast.ReturnStatement {
 
 	p.NextToken()
 
-	stmt.ReturnValue = p.ParseExpression(LOWEST)
+	stmt.ReturnValue = p.ParseExpressionSequence()
 
 	if p.PeekTokenIs(token.SEMICOLON) {
 		p.NextToken()
@@ -5,16 +5,19 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	// NewLine is set when a line break comes before the token.
+	NewLine bool
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
 
-	IDENT  = "IDENT"
-	INT    = "INT"
-	FLOAT  = "FLOAT"
-	STRING = "STRING"
+	IDENT   = "IDENT"
+	INT     = "INT"
+	FLOAT   = "FLOAT"
+	STRING  = "STRING"
+	FSTRING = "FSTRING"
 
 	// Operators
 	ASSIGN   = "="
@@ -34,17 +37,21 @@ const (
 	SHIFTL   = "<<"
 
 	// Comparasion
-	EQUAL   = "=="
-	UNEQUAL = "!="
-	LTEQ    = "<="
-	GTEQ    = ">="
-	LAND    = "&&"
-	LOR     = "||"
+	EQUAL    = "=="
+	UNEQUAL  = "!="
+	LTEQ     = "<="
+	GTEQ     = ">="
+	LAND     = "&&"
+	LOR      = "||"
+	ARROW    = "=>"
+	PIPELINE = "|>"
 
 	// Syntax Characters
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	DOT       = "."
+	QUESTION  = "?"
 	LPAREN    = "("
 	RPAREN    = ")"
 	LBRACE    = "{"
@@ -62,6 +69,10 @@ const (
 	ELSE     = "ELSE"
 	WHILE    = "WHILE"
 	FOR      = "FOR"
+	IN       = "IN"
+	NOT      = "NOT"
+	MATCH    = "MATCH"
+	CASE     = "CASE"
 )
 
 var keywords = map[string]TokenType{
@@ -74,6 +85,10 @@ var keywords = map[string]TokenType{
 	"while":  WHILE,
 	"for":    FOR,
 	"break":  BREAK,
+	"in":     IN,
+	"not":    NOT,
+	"match":  MATCH,
+	"case":   CASE,
 }
 
 func LookupIdent(ident string) TokenType {